@@ -0,0 +1,42 @@
+// Command backfill-lecturers links notes that only carry a free-text
+// lecturer name to lecturer records, creating records where needed.
+//
+//	go run ./cmd/backfill-lecturers [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/controllers"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would be linked without writing anything")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := config.ConnectDB(ctx); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer config.DisconnectDB(context.Background())
+
+	result, err := controllers.BackfillLecturers(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatal("Backfill failed:", err)
+	}
+
+	log.Printf("Found %d spellings: %d matched existing lecturers, %d new lecturers, %d skipped, %d notes linked",
+		result.Spellings, result.Matched, result.Created, result.Skipped, result.NotesLinked)
+}
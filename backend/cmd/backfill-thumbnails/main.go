@@ -0,0 +1,47 @@
+// Command backfill-thumbnails generates first-page thumbnails for notes that
+// were uploaded before thumbnails were rendered automatically.
+//
+//	go run ./cmd/backfill-thumbnails [-limit 50] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/controllers"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	limit := flag.Int64("limit", 0, "maximum number of notes to process (0 for all)")
+	dryRun := flag.Bool("dry-run", false, "list the notes that would be processed without uploading anything")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := config.ConnectDB(ctx); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer config.DisconnectDB(context.Background())
+
+	if err := config.InitCloudinary(); err != nil {
+		log.Fatal("Failed to initialize Cloudinary:", err)
+	}
+
+	result, err := controllers.BackfillNoteThumbnails(context.Background(), *limit, *dryRun)
+	if err != nil {
+		log.Fatal("Backfill failed:", err)
+	}
+
+	log.Printf("Processed %d notes: %d generated, %d skipped, %d failed",
+		result.Processed, result.Generated, result.Skipped, result.Failed)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// droppedIndexes lists indexes from earlier versions that conflict with the
+// current ones
+var droppedIndexes = map[string][]string{
+	// Course codes are unique per academic session, not globally
+	"courses": {"code_1"},
+	// Votes and ballots no longer record the voter; the voter roll does
+	"votes":   {"electionId_1_positionId_1_userId_1"},
+	"ballots": {"electionId_1_userId_1"},
+	// The voter roll has one entry per student per election, not per position
+	"voter_roll": {"electionId_1_positionId_1_userId_1"},
+}
+
+// EnsureIndexes creates the indexes the application relies on. Creating an
+// index that already exists is a no-op.
+func EnsureIndexes(ctx context.Context) error {
+	for collection, names := range droppedIndexes {
+		for _, name := range names {
+			_, err := GetCollection(collection).Indexes().DropOne(ctx, name)
+			if err != nil && !isIndexNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	indexes := map[string][]mongo.IndexModel{
+		"academic_sessions": {
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"courses": {
+			{Keys: bson.D{{Key: "session", Value: 1}, {Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "level", Value: 1}, {Key: "semester", Value: 1}}},
+		},
+		"registrations": {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "session", Value: 1}, {Key: "semester", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "session", Value: 1}, {Key: "semester", Value: 1}, {Key: "courses.courseCode", Value: 1}}},
+		},
+		"lecture_slots": {
+			{Keys: bson.D{{Key: "session", Value: 1}, {Key: "semester", Value: 1}, {Key: "day", Value: 1}}},
+		},
+		"exam_entries": {
+			{Keys: bson.D{{Key: "session", Value: 1}, {Key: "startsAt", Value: 1}}},
+		},
+		"users": {
+			{Keys: bson.D{{Key: "calendarToken", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		},
+		"voter_roll": {
+			// One entry per student per election; entries from before the roll
+			// was grouped have no positions until they are migrated
+			{Keys: bson.D{{Key: "electionId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"positions": bson.M{"$exists": true}})},
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+		},
+		"votes": {
+			{Keys: bson.D{{Key: "electionId", Value: 1}, {Key: "positionId", Value: 1}}},
+		},
+		"removed_votes": {
+			{Keys: bson.D{{Key: "electionId", Value: 1}, {Key: "positionId", Value: 1}}},
+		},
+		"ballots": {
+			{Keys: bson.D{{Key: "receipt", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// One ballot per link in an election's chain; older ballots have no seq
+			{Keys: bson.D{{Key: "electionId", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}})},
+		},
+		"uploads": {
+			{Keys: bson.D{{Key: "url", Value: 1}, {Key: "uploadedBy", Value: 1}}},
+		},
+		"nominations": {
+			// One application per student per election
+			{Keys: bson.D{{Key: "electionId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "electionId", Value: 1}, {Key: "status", Value: 1}}},
+		},
+		"lecturers": {
+			{Keys: bson.D{{Key: "courses.session", Value: 1}, {Key: "courses.courseCode", Value: 1}}},
+		},
+	}
+
+	for collection, models := range indexes {
+		if _, err := GetCollection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// isIndexNotFound reports whether dropping an index failed because it (or
+// its collection) doesn't exist
+func isIndexNotFound(err error) bool {
+	if cmdErr, ok := err.(mongo.CommandError); ok {
+		return cmdErr.Code == 27 || cmdErr.Code == 26 // IndexNotFound, NamespaceNotFound
+	}
+	return false
+}
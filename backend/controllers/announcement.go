@@ -30,18 +30,35 @@ func CreateAnnouncement(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var courseCodes []string
+	if len(req.CourseCodes) > 0 {
+		session, ok := resolveSession(c, ctx, "", time.Now())
+		if !ok {
+			return
+		}
+		for _, code := range req.CourseCodes {
+			course, ok := resolveActiveCourse(c, ctx, session, code)
+			if !ok {
+				return
+			}
+			courseCodes = append(courseCodes, course.Code)
+		}
+	}
+
 	announcement := models.Announcement{
-		ID:        primitive.NewObjectID(),
-		Title:     req.Title,
-		Content:   req.Content,
-		CreatedBy: userObjID,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          primitive.NewObjectID(),
+		Title:       req.Title,
+		Content:     req.Content,
+		CourseCodes: courseCodes,
+		CreatedBy:   userObjID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 
 	collection := config.GetCollection("announcements")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 
 	_, err = collection.InsertOne(ctx, announcement)
 	if err != nil {
@@ -57,9 +74,23 @@ func GetAnnouncements(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// With mine=true, only department-wide announcements and those for the
+	// user's registered courses
+	filter := bson.M{}
+	if c.Query("mine") == "true" {
+		codes, ok := myCourseCodes(c, ctx)
+		if !ok {
+			return
+		}
+		filter["$or"] = []bson.M{
+			{"courseCodes": bson.M{"$exists": false}},
+			{"courseCodes": bson.M{"$in": codes}},
+		}
+	}
+
 	// Sort by createdAt descending
 	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
-	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch announcements"})
 		return
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func recordAudit(ctx context.Context, entityType string, entityID primitive.ObjectID, action string, actorID primitive.ObjectID, changes map[string]models.FieldChange, reason string) error {
+	entry := models.AuditLog{
+		ID:         primitive.NewObjectID(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    changes,
+		Reason:     reason,
+		ActorID:    actorID,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err := config.GetCollection("audit_logs").InsertOne(ctx, entry)
+	return err
+}
+
+// getAuditHistory responds with the audit entries for a single record,
+// newest first
+func getAuditHistory(c *gin.Context, entityType string) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	collection := config.GetCollection("audit_logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"entityType": entityType, "entityId": objectID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.AuditLog
+	if err := cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode history"})
+		return
+	}
+
+	if entries == nil {
+		entries = []models.AuditLog{}
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
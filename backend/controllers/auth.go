@@ -73,6 +73,11 @@ func Register(c *gin.Context) {
 		}
 	}
 
+	entryYear := req.EntryYear
+	if entryYear == 0 {
+		entryYear = models.EstimateEntryYear(req.Level, time.Now())
+	}
+
 	// Create user
 	user := models.User{
 		ID:           primitive.NewObjectID(),
@@ -84,6 +89,7 @@ func Register(c *gin.Context) {
 		Password:     hashedPassword,
 		Role:         role,
 		Level:        req.Level,
+		EntryYear:    entryYear,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
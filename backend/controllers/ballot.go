@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// checkBallot validates a whole ballot: the election must be open to the
+// voter, every choice must pass the usual vote checks, no position may
+// appear twice, and every position the voter is eligible for must be
+// covered. Positions with no candidates can't be voted on, so they are left
+// out. The election is checked first so a ballot with no choices to check
+// can't get past it.
+func checkBallot(election models.Election, voter models.User, choices []models.BallotChoice, now time.Time) *voteRejection {
+	if rejection := checkElectionOpen(election, voter, now); rejection != nil {
+		return rejection
+	}
+
+	chosen := map[string]bool{}
+	for _, choice := range choices {
+		if chosen[choice.PositionID] {
+			return &voteRejection{http.StatusBadRequest, VoteErrDuplicatePosition, "Each position can only appear once on a ballot"}
+		}
+		chosen[choice.PositionID] = true
+
+		if rejection := checkVoteEligibility(election, voter, choice.PositionID, choice.CandidateID, now); rejection != nil {
+			return rejection
+		}
+	}
+
+	for _, position := range election.Positions {
+		if len(position.Candidates) == 0 {
+			continue
+		}
+		if (position.Level == 0 || position.Level == voter.Level) && !chosen[position.ID] {
+			return &voteRejection{http.StatusBadRequest, VoteErrIncompleteBallot, "Ballot is missing a choice for " + position.Title}
+		}
+	}
+	return nil
+}
+
+// ballotReceipt proves a ballot's place in its election's chain without
+// revealing its choices. chainLength is the number of ballots in the chain.
+func ballotReceipt(election models.Election, ballot models.Ballot, chainLength int64) models.BallotReceipt {
+	return models.BallotReceipt{
+		Receipt:       ballot.Receipt,
+		ElectionID:    election.ID,
+		ElectionTitle: election.Title,
+		Seq:           ballot.Seq,
+		PrevHash:      ballot.PrevHash,
+		Hash:          ballot.Hash,
+		ChainLength:   chainLength,
+		HashValid:     ballot.Hash != "" && ballot.Hash == ballotHash(ballot),
+	}
+}
+
+// CastBallot records a student's choices for every position in an election
+// at once. The voter roll entries, votes and ballot are written in a single
+// transaction, so either the whole ballot is counted or none of it is.
+func CastBallot(c *gin.Context) {
+	electionObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID", "code": VoteErrInvalidElectionID})
+		return
+	}
+
+	var req models.BallotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var election models.Election
+	err = config.GetCollection("elections").FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found", "code": VoteErrElectionNotFound})
+		return
+	}
+
+	voter, err := findUserByID(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "code": VoteErrVoterNotFound})
+		return
+	}
+
+	now := time.Now()
+	if rejection := checkBallot(election, voter, req.Choices, now); rejection != nil {
+		rejection.respond(c)
+		return
+	}
+
+	ballot, err := recordVotes(ctx, userObjectID, electionObjectID, req.Choices)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already voted in this election", "code": VoteErrAlreadyVoted})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record ballot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ballotReceipt(election, ballot, ballot.Seq))
+}
+
+// GetBallotByReceipt lets a voter check that their ballot was recorded and
+// is part of the election's chain. Ballots are anonymous, so the receipt is
+// the only way to find one. It only confirms inclusion, never the choices.
+func GetBallotByReceipt(c *gin.Context) {
+	// Receipts are lowercase hex now; older ones were short uppercase codes
+	receipt := strings.TrimSpace(c.Param("receipt"))
+	receipts := bson.A{strings.ToLower(receipt), strings.ToUpper(receipt)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var ballot models.Ballot
+	if err := config.GetCollection("ballots").FindOne(ctx, bson.M{"receipt": bson.M{"$in": receipts}}).Decode(&ballot); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ballot not found"})
+		return
+	}
+
+	var election models.Election
+	if err := config.GetCollection("elections").FindOne(ctx, bson.M{"_id": ballot.ElectionID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	var head models.BallotChain
+	err := config.GetCollection("ballot_chains").FindOne(ctx, bson.M{"_id": ballot.ElectionID}).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ballot chain"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ballotReceipt(election, ballot, head.Seq))
+}
@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errChainHeadMoved means the chain head changed between reading it and
+// appending to it, so the ballot would have forked the chain
+var errChainHeadMoved = errors.New("ballot chain head moved")
+
+// ballotHash is the SHA-256 of a ballot's election, position in the chain,
+// previous hash, choices (sorted by position) and nonce, hex-encoded. Only
+// the hash is published, and the random nonce stops the choices being
+// guessed from it by trying every combination; ballots chained before the
+// nonce was added have none.
+func ballotHash(ballot models.Ballot) string {
+	choices := make([]string, 0, len(ballot.Choices))
+	for _, choice := range ballot.Choices {
+		choices = append(choices, choice.PositionID+"="+choice.CandidateID)
+	}
+	sort.Strings(choices)
+
+	payload := fmt.Sprintf("%s|%d|%s|%s",
+		ballot.ElectionID.Hex(), ballot.Seq, ballot.PrevHash, strings.Join(choices, ";"))
+	if ballot.Nonce != "" {
+		payload += "|" + ballot.Nonce
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureBallotChain creates the empty chain head for an election if there
+// isn't one yet. It runs outside the voting transaction so two first voters
+// don't race to insert the head.
+func ensureBallotChain(ctx context.Context, electionID primitive.ObjectID) error {
+	_, err := config.GetCollection("ballot_chains").UpdateOne(
+		ctx,
+		bson.M{"_id": electionID},
+		bson.M{"$setOnInsert": bson.M{"seq": 0, "headHash": "", "legacyVotes": []models.LegacyVoteCount{}}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// appendToChain links a ballot onto the end of its election's chain, filling
+// in its sequence number, previous hash and hash, and moves the head. It
+// must run inside a transaction: two ballots appended at once both update
+// the head, so one hits a write conflict and is retried.
+func appendToChain(sc mongo.SessionContext, ballot *models.Ballot) error {
+	chains := config.GetCollection("ballot_chains")
+
+	var head models.BallotChain
+	if err := chains.FindOne(sc, bson.M{"_id": ballot.ElectionID}).Decode(&head); err != nil {
+		return err
+	}
+
+	ballot.Seq = head.Seq + 1
+	ballot.PrevHash = head.HeadHash
+	ballot.Hash = ballotHash(*ballot)
+
+	result, err := chains.UpdateOne(
+		sc,
+		bson.M{"_id": ballot.ElectionID, "seq": head.Seq},
+		bson.M{"$set": bson.M{"seq": ballot.Seq, "headHash": ballot.Hash}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errChainHeadMoved
+	}
+	return nil
+}
+
+// chainedBallots returns an election's ballots in chain order. Ballots cast
+// before the chain existed have no sequence number and are left out.
+func chainedBallots(ctx context.Context, electionID primitive.ObjectID) ([]models.Ballot, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	cursor, err := config.GetCollection("ballots").Find(ctx, bson.M{
+		"electionId": electionID,
+		"seq":        bson.M{"$exists": true},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ballots []models.Ballot
+	if err := cursor.All(ctx, &ballots); err != nil {
+		return nil, err
+	}
+	if ballots == nil {
+		ballots = []models.Ballot{}
+	}
+	return ballots, nil
+}
+
+// tallyBallots counts the votes for each candidate in a set of ballots
+func tallyBallots(ballots []models.Ballot) map[string]map[string]int64 {
+	counts := map[string]map[string]int64{}
+	for _, ballot := range ballots {
+		for _, choice := range ballot.Choices {
+			if counts[choice.PositionID] == nil {
+				counts[choice.PositionID] = map[string]int64{}
+			}
+			counts[choice.PositionID][choice.CandidateID]++
+		}
+	}
+	return counts
+}
+
+// RecordLegacyVotes stores on each election's chain head the votes that
+// were counted before the chain existed: whatever the tally has beyond the
+// chain. Verification then expects the tally to equal the chain plus these.
+// An election is only looked at once, so it must run at startup before
+// anyone can vote. It is safe to run repeatedly.
+func RecordLegacyVotes(ctx context.Context) error {
+	chains := config.GetCollection("ballot_chains")
+
+	electionIDs, err := config.GetCollection("votes").Distinct(ctx, "electionId", bson.M{})
+	if err != nil {
+		return err
+	}
+
+	recorded := 0
+	for _, value := range electionIDs {
+		electionID, ok := value.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+
+		count, err := chains.CountDocuments(ctx, bson.M{"_id": electionID, "legacyVotes": bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		ballots, err := chainedBallots(ctx, electionID)
+		if err != nil {
+			return err
+		}
+		chainCounts := tallyBallots(ballots)
+		counts, err := voteTallies(ctx, electionID)
+		if err != nil {
+			return err
+		}
+
+		legacy := []models.LegacyVoteCount{}
+		for positionID, candidates := range counts {
+			for candidateID, votes := range candidates {
+				if extra := votes - chainCounts[positionID][candidateID]; extra > 0 {
+					legacy = append(legacy, models.LegacyVoteCount{PositionID: positionID, CandidateID: candidateID, Votes: extra})
+				}
+			}
+		}
+		sort.Slice(legacy, func(i, j int) bool {
+			if legacy[i].PositionID != legacy[j].PositionID {
+				return legacy[i].PositionID < legacy[j].PositionID
+			}
+			return legacy[i].CandidateID < legacy[j].CandidateID
+		})
+
+		_, err = chains.UpdateOne(
+			ctx,
+			bson.M{"_id": electionID, "legacyVotes": bson.M{"$exists": false}},
+			bson.M{
+				"$set":         bson.M{"legacyVotes": legacy},
+				"$setOnInsert": bson.M{"seq": 0, "headHash": ""},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		if len(legacy) > 0 {
+			recorded++
+		}
+	}
+
+	if recorded > 0 {
+		log.Printf("Recorded legacy votes for %d elections", recorded)
+	}
+	return nil
+}
+
+// verifyBallotChain replays an election's chain from the first ballot,
+// checking every link and hash and that the chain ends at the stored head,
+// then compares the choices in the chain, plus any legacy votes, with the
+// counted votes
+func verifyBallotChain(ctx context.Context, electionID primitive.ObjectID) (models.ChainVerification, error) {
+	result := models.ChainVerification{ElectionID: electionID, Valid: true}
+
+	ballots, err := chainedBallots(ctx, electionID)
+	if err != nil {
+		return result, err
+	}
+
+	var head models.BallotChain
+	err = config.GetCollection("ballot_chains").FindOne(ctx, bson.M{"_id": electionID}).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return result, err
+	}
+
+	prevHash := ""
+	for i, ballot := range ballots {
+		if result.Valid && (ballot.Seq != int64(i+1) || ballot.PrevHash != prevHash || ballot.Hash != ballotHash(ballot)) {
+			result.Valid = false
+			result.BrokenAt = int64(i + 1)
+		}
+		prevHash = ballot.Hash
+		result.VotesInChain += int64(len(ballot.Choices))
+	}
+	result.Ballots = int64(len(ballots))
+	result.HeadHash = head.HeadHash
+
+	// A missing ballot at the end only shows against the head
+	if result.Valid && (head.Seq != result.Ballots || head.HeadHash != prevHash) {
+		result.Valid = false
+		result.BrokenAt = result.Ballots + 1
+	}
+
+	counts, err := voteTallies(ctx, electionID)
+	if err != nil {
+		return result, err
+	}
+
+	// Votes cast before the chain existed have no ballot, so they are
+	// reported separately and added to what the chain accounts for
+	expected := tallyBallots(ballots)
+	for _, legacy := range head.LegacyVotes {
+		if expected[legacy.PositionID] == nil {
+			expected[legacy.PositionID] = map[string]int64{}
+		}
+		expected[legacy.PositionID][legacy.CandidateID] += legacy.Votes
+		result.LegacyVotes += legacy.Votes
+	}
+
+	result.TallyMatches = true
+	for positionID, candidates := range counts {
+		for candidateID, count := range candidates {
+			result.VotesCounted += count
+			if expected[positionID][candidateID] != count {
+				result.TallyMatches = false
+			}
+		}
+	}
+	if result.VotesCounted != result.VotesInChain+result.LegacyVotes {
+		result.TallyMatches = false
+	}
+
+	return result, nil
+}
+
+// VerifyBallotChain lets anyone check that an election's ballot chain is
+// intact and matches the counted votes, and with ?receipt= that their own
+// ballot is part of it
+func VerifyBallotChain(c *gin.Context) {
+	electionObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	count, err := config.GetCollection("elections").CountDocuments(ctx, bson.M{"_id": electionObjectID})
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	result, err := verifyBallotChain(ctx, electionObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ballot chain"})
+		return
+	}
+
+	if receipt := strings.ToLower(strings.TrimSpace(c.Query("receipt"))); receipt != "" {
+		check := &models.ReceiptCheck{Receipt: receipt}
+		var ballot models.Ballot
+		err := config.GetCollection("ballots").FindOne(ctx, bson.M{
+			"electionId": electionObjectID,
+			"receipt":    receipt,
+		}).Decode(&ballot)
+		if err == nil {
+			check.Found = true
+			check.Seq = ballot.Seq
+		}
+		result.Receipt = check
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetBallotChain publishes an election's ballot chain so its links can be
+// checked independently. Like the tallies, it is only public once voting is
+// over. Each ballot's choices stay private: with them next to its hash, a
+// voter's place in the chain, or the order ballots were cast in, would show
+// how they voted. The choices are published only as totals.
+func GetBallotChain(c *gin.Context) {
+	electionObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var election models.Election
+	if err := config.GetCollection("elections").FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	role, _ := c.Get("userRole")
+	if role != "admin" && !electionClosed(election, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The ballot chain is published once the election closes"})
+		return
+	}
+
+	ballots, err := chainedBallots(ctx, electionObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ballot chain"})
+		return
+	}
+
+	var head models.BallotChain
+	err = config.GetCollection("ballot_chains").FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ballot chain"})
+		return
+	}
+
+	chain := models.PublishedChain{
+		ElectionID:  electionObjectID,
+		HeadHash:    head.HeadHash,
+		Links:       make([]models.ChainLink, 0, len(ballots)),
+		Tallies:     tallyBallots(ballots),
+		LegacyVotes: head.LegacyVotes,
+	}
+	for _, ballot := range ballots {
+		chain.Links = append(chain.Links, models.ChainLink{Seq: ballot.Seq, PrevHash: ballot.PrevHash, Hash: ballot.Hash})
+	}
+	if chain.LegacyVotes == nil {
+		chain.LegacyVotes = []models.LegacyVoteCount{}
+	}
+
+	c.JSON(http.StatusOK, chain)
+}
@@ -0,0 +1,518 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxBulkUploadBytes = 200 << 20
+	// maxBulkUploadFileBytes caps each file taken out of an archive, so a
+	// small archive can't expand into something that exhausts memory
+	maxBulkUploadFileBytes = 50 << 20
+)
+
+var errZipFileTooLarge = fmt.Errorf("file is larger than %d MB", maxBulkUploadFileBytes>>20)
+
+var bulkUploadExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".jpg": true, ".jpeg": true, ".png": true,
+}
+
+// bulkManifestColumns maps accepted CSV header spellings to manifest fields
+var bulkManifestColumns = map[string]string{
+	"coursecode":  "courseCode",
+	"course_code": "courseCode",
+	"course code": "courseCode",
+	"year":        "year",
+	"semester":    "semester",
+	"session":     "session",
+	"filename":    "fileName",
+	"file_name":   "fileName",
+	"file name":   "fileName",
+	"file":        "fileName",
+	"title":       "title",
+	"description": "description",
+}
+
+// parseBulkManifest reads a CSV or JSON manifest. Rows that can't be parsed
+// are reported in rowErrors keyed by their 1-based row number.
+func parseBulkManifest(name string, data []byte) ([]models.BulkManifestRow, map[int]string, error) {
+	rowErrors := map[int]string{}
+
+	if strings.EqualFold(path.Ext(name), ".json") {
+		var rows []models.BulkManifestRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON manifest: %v", err)
+		}
+		return rows, rowErrors, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV manifest: %v", err)
+	}
+	if len(records) < 2 {
+		return nil, nil, errors.New("manifest must have a header row and at least one file")
+	}
+
+	columns := map[string]int{}
+	for i, header := range records[0] {
+		if field, ok := bulkManifestColumns[strings.ToLower(strings.TrimSpace(header))]; ok {
+			columns[field] = i
+		}
+	}
+	for _, required := range []string{"courseCode", "year", "fileName"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("manifest is missing the %s column", required)
+		}
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]models.BulkManifestRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := models.BulkManifestRow{
+			CourseCode:  value(record, "courseCode"),
+			Semester:    value(record, "semester"),
+			Session:     value(record, "session"),
+			FileName:    value(record, "fileName"),
+			Title:       value(record, "title"),
+			Description: value(record, "description"),
+		}
+		year, err := strconv.Atoi(value(record, "year"))
+		if err != nil {
+			rowErrors[i+1] = "invalid year " + strconv.Quote(value(record, "year"))
+		}
+		row.Year = year
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func readFormFile(c *gin.Context, field string) (string, []byte, error) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		return "", nil, err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	return header.Filename, data, err
+}
+
+// findArchiveFile matches a manifest file name against the archive, first by
+// full path and then by base name
+func findArchiveFile(archive *zip.Reader, name string) *zip.File {
+	var byBase *zip.File
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if file.Name == name {
+			return file
+		}
+		if byBase == nil && path.Base(file.Name) == path.Base(name) {
+			byBase = file
+		}
+	}
+	return byBase
+}
+
+// BulkUploadPastQuestions accepts a ZIP of past question files plus a CSV or
+// JSON manifest. Every row is checked against the course catalog up front;
+// valid rows are then uploaded and saved in the background and the job can
+// be polled for a per-item report.
+func BulkUploadPastQuestions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBulkUploadBytes)
+
+	_, archiveData, err := readFormFile(c, "archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A ZIP archive is required in the archive field"})
+		return
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(archiveData), int64(len(archiveData)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive is not a valid ZIP file"})
+		return
+	}
+
+	// The manifest can be uploaded separately or included in the archive
+	manifestName, manifestData, err := readFormFile(c, "manifest")
+	if err != nil {
+		for _, candidate := range []string{"manifest.csv", "manifest.json"} {
+			if file := findArchiveFile(archive, candidate); file != nil {
+				manifestName = candidate
+				manifestData, err = readZipFile(file)
+				break
+			}
+		}
+	}
+	if errors.Is(err, errZipFileTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Manifest " + err.Error()})
+		return
+	}
+	if err != nil || manifestData == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A manifest.csv or manifest.json is required"})
+		return
+	}
+
+	rows, rowErrors, err := parseBulkManifest(manifestName, manifestData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	job := models.BulkUploadJob{
+		ID:        primitive.NewObjectID(),
+		Status:    "processing",
+		Total:     len(rows),
+		Items:     make([]models.BulkUploadItem, len(rows)),
+		CreatedBy: userObjID,
+		CreatedAt: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+
+	pending := make([]bulkUploadTask, 0, len(rows))
+	seenFiles := map[string]int{}
+	currentYear := time.Now().Year()
+
+	for i, row := range rows {
+		item := models.BulkUploadItem{
+			Row:        i + 1,
+			FileName:   row.FileName,
+			CourseCode: row.CourseCode,
+			Year:       row.Year,
+			Status:     "invalid",
+		}
+
+		session, sessionFound := snapshot.session(row.Session)
+		if row.Session == "" {
+			session, sessionFound = snapshot.sessionForExamYear(row.Year)
+		}
+		course, found := snapshot.course(session.Name, row.CourseCode)
+		file := findArchiveFile(archive, row.FileName)
+
+		switch {
+		case rowErrors[i+1] != "":
+			item.Error = rowErrors[i+1]
+		case !sessionFound:
+			item.Error = "unknown academic session " + strconv.Quote(row.Session)
+		case !found:
+			item.Error = fmt.Sprintf("unknown course code %q for the %s session", row.CourseCode, session.Name)
+		case course.Retired:
+			item.Error = course.Code + " has been retired from the " + session.Name + " catalog"
+		case row.Semester != "" && models.NormalizeSemester(row.Semester) != course.Semester:
+			item.Error = fmt.Sprintf("%s is a %s semester course", course.Code, course.Semester)
+		case row.Year < 1990 || row.Year > currentYear:
+			item.Error = fmt.Sprintf("year must be between 1990 and %d", currentYear)
+		case row.FileName == "":
+			item.Error = "file name is required"
+		case seenFiles[row.FileName] != 0:
+			item.Error = fmt.Sprintf("file already used by row %d", seenFiles[row.FileName])
+		case file == nil:
+			item.Error = "file not found in archive"
+		case !bulkUploadExtensions[strings.ToLower(path.Ext(row.FileName))]:
+			item.Error = "unsupported file type"
+		case file.UncompressedSize64 > maxBulkUploadFileBytes:
+			item.Error = errZipFileTooLarge.Error()
+		default:
+			item.CourseCode = course.Code
+			item.Status = "pending"
+			seenFiles[row.FileName] = i + 1
+			pending = append(pending, bulkUploadTask{index: i, row: row, course: course, file: file})
+		}
+
+		if item.Status == "invalid" {
+			job.Failed++
+		}
+		job.Items[i] = item
+	}
+
+	if len(pending) == 0 {
+		now := time.Now()
+		job.Status = "failed"
+		job.CompletedAt = &now
+	}
+
+	collection := config.GetCollection("bulk_upload_jobs")
+	if _, err := collection.InsertOne(ctx, job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload job"})
+		return
+	}
+
+	if len(pending) > 0 {
+		go processBulkUpload(job, pending)
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+type bulkUploadTask struct {
+	index  int
+	row    models.BulkManifestRow
+	course models.Course
+	file   *zip.File
+}
+
+// readZipFile reads a file out of an archive, refusing any larger than
+// maxBulkUploadFileBytes. The size the archive declares is checked first,
+// and the read stops at the limit in case the declared size is wrong.
+func readZipFile(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > maxBulkUploadFileBytes {
+		return nil, errZipFileTooLarge
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxBulkUploadFileBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBulkUploadFileBytes {
+		return nil, errZipFileTooLarge
+	}
+	return data, nil
+}
+
+// processBulkUpload uploads every validated file and then saves all of the
+// past questions with a single insert
+func processBulkUpload(job models.BulkUploadJob, tasks []bulkUploadTask) {
+	falsePtr := false
+	truePtr := true
+
+	var pastQuestions []interface{}
+	var uploaded []bulkUploadTask
+	var uploadedURLs []string
+
+	for _, task := range tasks {
+		item := &job.Items[task.index]
+
+		data, err := readZipFile(task.file)
+		if err != nil {
+			item.Status = "failed"
+			item.Error = "could not read file from archive"
+			if errors.Is(err, errZipFileTooLarge) {
+				item.Error = err.Error()
+			}
+			job.Failed++
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+		result, err := config.CloudinaryInstance.Upload.Upload(
+			ctx,
+			bytes.NewReader(data),
+			uploader.UploadParams{
+				Folder:         "past-questions",
+				PublicID:       fmt.Sprintf("%d_%d_%s", time.Now().Unix(), task.index+1, cleanFilename(path.Base(task.row.FileName))),
+				UseFilename:    &falsePtr,
+				UniqueFilename: &falsePtr,
+				Overwrite:      &truePtr,
+			},
+		)
+		cancel()
+		if err == nil && result.Error.Message != "" {
+			err = errors.New(result.Error.Message)
+		}
+		if err != nil {
+			item.Status = "failed"
+			item.Error = fmt.Sprintf("upload failed: %v", err)
+			job.Failed++
+			continue
+		}
+
+		title := task.row.Title
+		if title == "" {
+			title = fmt.Sprintf("%s %s %d", task.course.Code, task.course.Name, task.row.Year)
+		}
+
+		pastQuestion := models.PastQuestion{
+			ID:          primitive.NewObjectID(),
+			Title:       title,
+			Description: task.row.Description,
+			Course:      task.course.Name,
+			CourseCode:  task.course.Code,
+			Level:       task.course.Level,
+			Semester:    task.course.Semester,
+			Year:        task.row.Year,
+			Session:     task.course.Session,
+			FileURL:     result.SecureURL,
+			FileName:    path.Base(task.row.FileName),
+			UploadedBy:  job.CreatedBy,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		pastQuestions = append(pastQuestions, pastQuestion)
+		uploaded = append(uploaded, task)
+		uploadedURLs = append(uploadedURLs, result.SecureURL)
+
+		id := pastQuestion.ID
+		item.PastQuestionID = &id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if len(pastQuestions) > 0 {
+		_, err := config.GetCollection("pastquestions").InsertMany(ctx, pastQuestions)
+		if err != nil {
+			log.Printf("Bulk upload %s failed to save past questions: %v", job.ID.Hex(), err)
+			for _, task := range uploaded {
+				item := &job.Items[task.index]
+				item.Status = "failed"
+				item.Error = "failed to save past question"
+				item.PastQuestionID = nil
+			}
+			job.Failed += len(uploaded)
+			removeStoredFiles("bulk upload "+job.ID.Hex()+" not saved", uploadedURLs...)
+		} else {
+			for _, task := range uploaded {
+				job.Items[task.index].Status = "created"
+			}
+			job.Created = len(uploaded)
+		}
+	}
+
+	now := time.Now()
+	job.Status = "completed"
+	if job.Created == 0 {
+		job.Status = "failed"
+	}
+	job.CompletedAt = &now
+
+	_, err := config.GetCollection("bulk_upload_jobs").UpdateOne(
+		ctx,
+		bson.M{"_id": job.ID},
+		bson.M{"$set": bson.M{
+			"status":      job.Status,
+			"created":     job.Created,
+			"failed":      job.Failed,
+			"items":       job.Items,
+			"completedAt": job.CompletedAt,
+		}},
+	)
+	if err != nil {
+		log.Printf("Failed to save bulk upload report %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// FailInterruptedBulkUploads marks jobs still processing when the server
+// started as failed. Their uploads ran in a goroutine that no longer exists,
+// so they would otherwise stay in processing forever.
+func FailInterruptedBulkUploads(ctx context.Context) error {
+	collection := config.GetCollection("bulk_upload_jobs")
+
+	cursor, err := collection.Find(ctx, bson.M{"status": "processing"})
+	if err != nil {
+		return err
+	}
+	var jobs []models.BulkUploadJob
+	err = cursor.All(ctx, &jobs)
+	cursor.Close(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		for i := range job.Items {
+			if job.Items[i].Status == "pending" {
+				job.Items[i].Status = "failed"
+				job.Items[i].Error = "upload was interrupted"
+				job.Failed++
+			}
+		}
+
+		now := time.Now()
+		_, err := collection.UpdateOne(
+			ctx,
+			bson.M{"_id": job.ID, "status": "processing"},
+			bson.M{"$set": bson.M{
+				"status":      "failed",
+				"error":       "Server restarted while the upload was processing",
+				"failed":      job.Failed,
+				"items":       job.Items,
+				"completedAt": now,
+			}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(jobs) > 0 {
+		log.Printf("Marked %d interrupted bulk uploads as failed", len(jobs))
+	}
+	return nil
+}
+
+func GetBulkUploadJob(c *gin.Context) {
+	id := c.Param("jobId")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	collection := config.GetCollection("bulk_upload_jobs")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var job models.BulkUploadJob
+	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&job)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
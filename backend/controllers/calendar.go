@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+	"bowen-accounting-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateCalendarToken issues (or replaces) the secret in the logged-in
+// user's personal .ics feed URL. Calendar apps can't send auth headers, so
+// the token in the URL is what identifies the user.
+func CreateCalendarToken(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	objectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	token, err := utils.RandomToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate calendar token"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = config.GetCollection("users").UpdateOne(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{"calendarToken": token, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save calendar token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":   token,
+		"feedUrl": "/api/schedule/feeds/student/" + token + ".ics",
+	})
+}
+
+// GetStudentCalendarFeed serves the lectures and exams of the courses a
+// student registered for this session
+func GetStudentCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if token == "" || config.GetCollection("users").FindOne(ctx, bson.M{"calendarToken": token}).Decode(&user) != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		return
+	}
+
+	session, ok := resolveSession(c, ctx, "", time.Now())
+	if !ok {
+		return
+	}
+
+	codes, err := registeredCourseCodes(ctx, user.ID, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch registrations"})
+		return
+	}
+
+	filter := bson.M{"session": session, "courseCode": bson.M{"$in": codes}}
+	writeCalendarFeed(c, ctx, "My timetable "+session, "timetable.ics", session, filter)
+}
+
+// GetLevelCalendarFeed serves every lecture and exam for a level, for the
+// session in ?session= (default current) and optionally one ?semester=
+func GetLevelCalendarFeed(c *gin.Context) {
+	level, err := strconv.Atoi(strings.TrimSuffix(c.Param("level"), ".ics"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, c.Query("session"), time.Now())
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"session": session, "level": level}
+	if semester := c.Query("semester"); semester != "" {
+		filter["semester"] = models.NormalizeSemester(semester)
+	}
+
+	name := fmt.Sprintf("%d Level timetable %s", level, session)
+	writeCalendarFeed(c, ctx, name, fmt.Sprintf("%d-level.ics", level), session, filter)
+}
+
+// writeCalendarFeed turns the lecture slots and exam entries matching filter
+// into an iCalendar response. Lectures repeat weekly for their semester.
+func writeCalendarFeed(c *gin.Context, ctx context.Context, name, filename, sessionName string, filter bson.M) {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+	session, _ := snapshot.session(sessionName)
+
+	cursor, err := config.GetCollection("lecture_slots").Find(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lecture slots"})
+		return
+	}
+	var slots []models.LectureSlot
+	err = cursor.All(ctx, &slots)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode lecture slots"})
+		return
+	}
+
+	cursor, err = config.GetCollection("exam_entries").Find(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exam timetable"})
+		return
+	}
+	var exams []models.ExamEntry
+	err = cursor.All(ctx, &exams)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode exam timetable"})
+		return
+	}
+
+	events := make([]utils.ICalEvent, 0, len(slots)+len(exams))
+	for _, slot := range slots {
+		semesterStart, semesterEnd := semesterRange(session, slot.Semester)
+		start, end := firstLecture(slot, semesterStart)
+		events = append(events, utils.ICalEvent{
+			UID:         slot.ID.Hex() + "@lecture.bowen-accounting",
+			Summary:     slot.CourseCode + " Lecture",
+			Location:    slot.Venue,
+			Description: slot.CourseName,
+			Start:       start,
+			End:         end,
+			WeeklyUntil: semesterEnd,
+			Updated:     slot.UpdatedAt,
+		})
+	}
+	for _, exam := range exams {
+		events = append(events, utils.ICalEvent{
+			UID:         exam.ID.Hex() + "@exam.bowen-accounting",
+			Summary:     exam.CourseCode + " Exam",
+			Location:    exam.Venue,
+			Description: exam.CourseName,
+			Start:       exam.StartsAt,
+			End:         exam.EndsAt,
+			Updated:     exam.UpdatedAt,
+		})
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", "inline; filename="+filename)
+	c.Status(http.StatusOK)
+	utils.WriteICal(c.Writer, name, events)
+}
+
+// firstLecture returns the first occurrence of a weekly slot on or after
+// the start of its semester
+func firstLecture(slot models.LectureSlot, semesterStart time.Time) (time.Time, time.Time) {
+	day := semesterStart.In(scheduleLocation)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, scheduleLocation)
+	for day.Weekday() != scheduleWeekdays[slot.Day] {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+	return at(slot.StartTime), at(slot.EndTime)
+}
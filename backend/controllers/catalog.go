@@ -0,0 +1,382 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const catalogCacheTTL = 5 * time.Minute
+
+// catalogSnapshot is the academic sessions and every session's version of
+// the course catalog, as loaded at one point in time
+type catalogSnapshot struct {
+	sessions  []models.AcademicSession // ordered by start date
+	courses   []models.Course
+	bySession map[string]map[string]models.Course
+}
+
+// courseCatalog caches the courses and academic_sessions collections in
+// memory. Writes through the admin endpoints invalidate it; anything else is
+// picked up after the TTL.
+type courseCatalog struct {
+	mu       sync.RWMutex
+	snapshot *catalogSnapshot
+	loadedAt time.Time
+}
+
+var catalog = &courseCatalog{}
+
+func (cat *courseCatalog) load(ctx context.Context) (*catalogSnapshot, error) {
+	cat.mu.RLock()
+	if cat.snapshot != nil && time.Since(cat.loadedAt) < catalogCacheTTL {
+		snapshot := cat.snapshot
+		cat.mu.RUnlock()
+		return snapshot, nil
+	}
+	cat.mu.RUnlock()
+
+	snapshot := &catalogSnapshot{bySession: map[string]map[string]models.Course{}}
+
+	cursor, err := config.GetCollection("academic_sessions").Find(
+		ctx,
+		bson.M{},
+		options.Find().SetSort(bson.D{{Key: "startDate", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &snapshot.sessions)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err = config.GetCollection("courses").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &snapshot.courses)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(snapshot.courses, func(i, j int) bool {
+		a, b := snapshot.courses[i], snapshot.courses[j]
+		if a.Level != b.Level {
+			return a.Level < b.Level
+		}
+		if a.Semester != b.Semester {
+			return a.Semester < b.Semester
+		}
+		return a.Code < b.Code
+	})
+
+	for _, course := range snapshot.courses {
+		if snapshot.bySession[course.Session] == nil {
+			snapshot.bySession[course.Session] = map[string]models.Course{}
+		}
+		snapshot.bySession[course.Session][course.Code] = course
+	}
+
+	cat.mu.Lock()
+	cat.snapshot, cat.loadedAt = snapshot, time.Now()
+	cat.mu.Unlock()
+
+	return snapshot, nil
+}
+
+func (cat *courseCatalog) invalidate() {
+	cat.mu.Lock()
+	cat.snapshot = nil
+	cat.mu.Unlock()
+}
+
+// session looks a session up by name
+func (s *catalogSnapshot) session(name string) (models.AcademicSession, bool) {
+	for _, session := range s.sessions {
+		if session.Name == name {
+			return session, true
+		}
+	}
+	return models.AcademicSession{}, false
+}
+
+// sessionAt returns the session in effect at t. Dates between sessions
+// belong to the one before; dates before the first session belong to it.
+func (s *catalogSnapshot) sessionAt(t time.Time) (models.AcademicSession, bool) {
+	if len(s.sessions) == 0 {
+		return models.AcademicSession{}, false
+	}
+	found := s.sessions[0]
+	for _, session := range s.sessions {
+		if session.StartDate.After(t) {
+			break
+		}
+		found = session
+	}
+	return found, true
+}
+
+// sessionForExamYear maps a past question's year onto the session the exam
+// was sat in. Both semesters' exams of a Sep-Aug session fall in its second
+// calendar year, so the middle of that year is used.
+func (s *catalogSnapshot) sessionForExamYear(year int) (models.AcademicSession, bool) {
+	return s.sessionAt(examDate(year))
+}
+
+func examDate(year int) time.Time {
+	return time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// sessionForEntryYear returns the session a student who started in the
+// given year was admitted under
+func (s *catalogSnapshot) sessionForEntryYear(year int) (models.AcademicSession, bool) {
+	return s.sessionAt(time.Date(year, time.October, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// course looks a course up in one session's catalog, accepting
+// non-canonical spellings such as "acc301"
+func (s *catalogSnapshot) course(session, code string) (models.Course, bool) {
+	course, ok := s.bySession[session][models.NormalizeCourseCode(code)]
+	return course, ok
+}
+
+// listCourses returns the session's courses matching the level and semester
+// (zero values match everything), leaving out retired courses unless asked
+func listCourses(ctx context.Context, session string, level int, semester string, includeRetired bool) ([]models.Course, error) {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	courses := []models.Course{}
+	for _, course := range snapshot.courses {
+		if course.Session != session {
+			continue
+		}
+		if level != 0 && course.Level != level {
+			continue
+		}
+		if semester != "" && course.Semester != semester {
+			continue
+		}
+		if course.Retired && !includeRetired {
+			continue
+		}
+		courses = append(courses, course)
+	}
+	return courses, nil
+}
+
+// findCourse looks a course up in a session's catalog. Retired courses are
+// returned too; callers creating new content should check Retired.
+func findCourse(ctx context.Context, session, code string) (models.Course, bool, error) {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		return models.Course{}, false, err
+	}
+
+	course, ok := snapshot.course(session, code)
+	return course, ok, nil
+}
+
+// resolveSession validates a requested session name, or when none is given
+// picks the session in effect at the given time. On failure it writes the
+// error response and returns false.
+func resolveSession(c *gin.Context, ctx context.Context, name string, at time.Time) (string, bool) {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return "", false
+	}
+
+	var session models.AcademicSession
+	var ok bool
+	if name != "" {
+		session, ok = snapshot.session(name)
+	} else {
+		session, ok = snapshot.sessionAt(at)
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown academic session: " + name})
+		return "", false
+	}
+	return session.Name, true
+}
+
+// sessionFromQuery picks the catalog session for a listing request: an
+// explicit ?session=, the session a student with ?entryYear= was admitted
+// under, or the current session
+func sessionFromQuery(c *gin.Context, ctx context.Context) (string, bool) {
+	if entryYear := c.Query("entryYear"); entryYear != "" && c.Query("session") == "" {
+		year, err := strconv.Atoi(entryYear)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entryYear"})
+			return "", false
+		}
+		return sessionForEntryYear(c, ctx, year)
+	}
+	return resolveSession(c, ctx, c.Query("session"), time.Now())
+}
+
+func sessionForEntryYear(c *gin.Context, ctx context.Context, year int) (string, bool) {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return "", false
+	}
+	session, ok := snapshot.sessionForEntryYear(year)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No academic sessions have been set up"})
+		return "", false
+	}
+	return session.Name, true
+}
+
+// SeedCourseCatalog makes sure there is at least one academic session and
+// copies the built-in course list into the first one. Courses that already
+// exist are left alone so admin edits survive restarts. Courses, notes and
+// past questions stored before sessions existed are assigned one here.
+func SeedCourseCatalog(ctx context.Context) error {
+	sessions := config.GetCollection("academic_sessions")
+
+	count, err := sessions.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		initial := models.DefaultSessionFor(time.Now())
+		initial.ID = primitive.NewObjectID()
+		initial.CreatedAt = time.Now()
+		initial.UpdatedAt = time.Now()
+		if _, err := sessions.InsertOne(ctx, initial); err != nil {
+			return err
+		}
+	}
+
+	var first models.AcademicSession
+	err = sessions.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "startDate", Value: 1}})).Decode(&first)
+	if err != nil {
+		return err
+	}
+
+	// The catalog before sessions existed becomes the first session's
+	_, err = config.GetCollection("courses").UpdateMany(
+		ctx,
+		bson.M{"session": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"session": first.Name}},
+	)
+	if err != nil {
+		return err
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(models.SeedCourses))
+	now := time.Now()
+	for _, course := range models.SeedCourses {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"session": first.Name, "code": course.Code}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{
+				"session":   first.Name,
+				"code":      course.Code,
+				"name":      course.Name,
+				"level":     course.Level,
+				"semester":  course.Semester,
+				"retired":   false,
+				"createdAt": now,
+				"updatedAt": now,
+			}}).
+			SetUpsert(true))
+	}
+
+	_, err = config.GetCollection("courses").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	catalog.invalidate()
+	if err != nil {
+		return err
+	}
+
+	return assignContentSessions(ctx)
+}
+
+// assignContentSessions fills in the session on notes (by upload date) and
+// past questions (by exam year) that don't have one
+func assignContentSessions(ctx context.Context) error {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, collectionName := range []string{"notes", "pastquestions"} {
+		collection := config.GetCollection(collectionName)
+		cursor, err := collection.Find(
+			ctx,
+			bson.M{"session": bson.M{"$exists": false}},
+			options.Find().SetProjection(bson.M{"createdAt": 1, "year": 1}),
+		)
+		if err != nil {
+			return err
+		}
+
+		var records []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			CreatedAt time.Time          `bson:"createdAt"`
+			Year      int                `bson:"year"`
+		}
+		err = cursor.All(ctx, &records)
+		cursor.Close(ctx)
+		if err != nil {
+			return err
+		}
+
+		var writes []mongo.WriteModel
+		for _, record := range records {
+			session, _ := snapshot.sessionAt(record.CreatedAt)
+			if collectionName == "pastquestions" {
+				session, _ = snapshot.sessionForExamYear(record.Year)
+			}
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": record.ID}).
+				SetUpdate(bson.M{"$set": bson.M{"session": session.Name}}))
+		}
+		if len(writes) == 0 {
+			continue
+		}
+		if _, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveActiveCourse finds the catalog course for new or edited content in
+// the given session. On failure it writes the error response and returns
+// false.
+func resolveActiveCourse(c *gin.Context, ctx context.Context, session, code string) (models.Course, bool) {
+	course, ok, err := findCourse(ctx, session, code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return models.Course{}, false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown course code for the " + session + " session: " + code})
+		return models.Course{}, false
+	}
+	if course.Retired {
+		c.JSON(http.StatusBadRequest, gin.H{"error": course.Code + " has been retired from the " + session + " catalog"})
+		return models.Course{}, false
+	}
+	return course, true
+}
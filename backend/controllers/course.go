@@ -1,16 +1,40 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"bowen-accounting-backend/config"
 	"bowen-accounting-backend/models"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func GetAllCourses(c *gin.Context) {
-	c.JSON(http.StatusOK, models.Courses)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := sessionFromQuery(c, ctx)
+	if !ok {
+		return
+	}
+
+	courses, err := listCourses(ctx, session, 0, "", c.Query("includeRetired") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, courses)
 }
 
 func GetCoursesByLevel(c *gin.Context) {
@@ -21,7 +45,20 @@ func GetCoursesByLevel(c *gin.Context) {
 		return
 	}
 
-	courses := models.GetCoursesByLevel(level)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := sessionFromQuery(c, ctx)
+	if !ok {
+		return
+	}
+
+	courses, err := listCourses(ctx, session, level, "", false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courses"})
+		return
+	}
+
 	c.JSON(http.StatusOK, courses)
 }
 
@@ -35,6 +72,314 @@ func GetCoursesByLevelAndSemester(c *gin.Context) {
 		return
 	}
 
-	courses := models.GetCoursesByLevelAndSemester(level, semester)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := sessionFromQuery(c, ctx)
+	if !ok {
+		return
+	}
+
+	courses, err := listCourses(ctx, session, level, models.NormalizeSemester(semester), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courses"})
+		return
+	}
+
 	c.JSON(http.StatusOK, courses)
 }
+
+// GetMyCurriculum returns the courses of the session the logged-in student
+// was admitted under, optionally narrowed by ?level= and ?semester=
+func GetMyCurriculum(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	objectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := findUserByID(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	entryYear := user.EntryYear
+	if entryYear == 0 {
+		entryYear = models.EstimateEntryYear(user.Level, time.Now())
+	}
+
+	session, ok := sessionForEntryYear(c, ctx, entryYear)
+	if !ok {
+		return
+	}
+
+	level := 0
+	if levelStr := c.Query("level"); levelStr != "" {
+		if level, err = strconv.Atoi(levelStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return
+		}
+	}
+
+	courses, err := listCourses(ctx, session, level, models.NormalizeSemester(c.Query("semester")), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entryYear": entryYear,
+		"session":   session,
+		"courses":   courses,
+	})
+}
+
+func CreateCourse(c *gin.Context) {
+	var req models.CreateCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	semester := models.NormalizeSemester(req.Semester)
+	if semester != "first" && semester != "second" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Semester must be first or second"})
+		return
+	}
+
+	collection := config.GetCollection("courses")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, req.Session, time.Now())
+	if !ok {
+		return
+	}
+
+	course := models.Course{
+		ID:        primitive.NewObjectID(),
+		Session:   session,
+		Code:      models.NormalizeCourseCode(req.Code),
+		Name:      strings.TrimSpace(req.Name),
+		Level:     req.Level,
+		Semester:  semester,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	_, err := collection.InsertOne(ctx, course)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A course with this code already exists in the " + session + " session"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create course"})
+		return
+	}
+
+	catalog.invalidate()
+
+	c.JSON(http.StatusCreated, course)
+}
+
+// UpdateCourse changes a course's name, level, semester or retired flag in
+// one session's catalog (?session=, default current). The course details
+// copied onto that session's notes and past questions are updated to match.
+func UpdateCourse(c *gin.Context) {
+	code := models.NormalizeCourseCode(c.Param("code"))
+
+	var req models.UpdateCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set := bson.M{}
+	if req.Name != nil {
+		set["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Level != nil {
+		set["level"] = *req.Level
+	}
+	if req.Semester != nil {
+		semester := models.NormalizeSemester(*req.Semester)
+		if semester != "first" && semester != "second" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Semester must be first or second"})
+			return
+		}
+		set["semester"] = semester
+	}
+	if req.Retired != nil {
+		set["retired"] = *req.Retired
+	}
+	if len(set) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+	set["updatedAt"] = time.Now()
+
+	collection := config.GetCollection("courses")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, c.Query("session"), time.Now())
+	if !ok {
+		return
+	}
+
+	var course models.Course
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"session": session, "code": code},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&course)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update course"})
+		return
+	}
+
+	catalog.invalidate()
+
+	// Keep the denormalised course details on existing content in step
+	if req.Name != nil || req.Level != nil || req.Semester != nil {
+		contentUpdate := bson.M{"$set": bson.M{
+			"course":   course.Name,
+			"level":    course.Level,
+			"semester": course.Semester,
+		}}
+		for _, collectionName := range []string{"notes", "pastquestions"} {
+			if _, err := config.GetCollection(collectionName).UpdateMany(ctx, bson.M{"session": session, "courseCode": code}, contentUpdate); err != nil {
+				log.Printf("Failed to update %s for course %s: %v", collectionName, code, err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// RetireCourse hides a course from one session's catalog without touching
+// the notes and past questions that already use it
+func RetireCourse(c *gin.Context) {
+	code := models.NormalizeCourseCode(c.Param("code"))
+
+	collection := config.GetCollection("courses")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, c.Query("session"), time.Now())
+	if !ok {
+		return
+	}
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"session": session, "code": code},
+		bson.M{"$set": bson.M{
+			"retired":   true,
+			"updatedAt": time.Now(),
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retire course"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	catalog.invalidate()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Course retired successfully"})
+}
+
+// GetCatalogMismatches reports notes and past questions whose course code,
+// name, level or semester don't match their session's course catalog
+func GetCatalogMismatches(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	mismatches := []models.CatalogMismatch{}
+	for _, collectionName := range []string{"notes", "pastquestions"} {
+		cursor, err := config.GetCollection(collectionName).Find(ctx, bson.M{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch " + collectionName})
+			return
+		}
+
+		var records []struct {
+			ID         primitive.ObjectID `bson:"_id"`
+			Title      string             `bson:"title"`
+			Course     string             `bson:"course"`
+			CourseCode string             `bson:"courseCode"`
+			Level      int                `bson:"level"`
+			Semester   string             `bson:"semester"`
+			Session    string             `bson:"session"`
+		}
+		err = cursor.All(ctx, &records)
+		cursor.Close(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode " + collectionName})
+			return
+		}
+
+		for _, record := range records {
+			var issues []string
+			suggested := ""
+
+			course, ok, err := findCourse(ctx, record.Session, record.CourseCode)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+				return
+			}
+			if record.Session == "" {
+				issues = append(issues, "no academic session")
+			} else if !ok {
+				issues = append(issues, "course code is not in the "+record.Session+" catalog")
+			} else {
+				if course.Code != record.CourseCode {
+					issues = append(issues, "course code is not in canonical form")
+					suggested = course.Code
+				}
+				if course.Name != record.Course {
+					issues = append(issues, fmt.Sprintf("course name should be %q", course.Name))
+				}
+				if course.Level != record.Level {
+					issues = append(issues, fmt.Sprintf("level should be %d", course.Level))
+				}
+				if course.Semester != record.Semester {
+					issues = append(issues, fmt.Sprintf("semester should be %q", course.Semester))
+				}
+			}
+
+			if len(issues) == 0 {
+				continue
+			}
+
+			mismatches = append(mismatches, models.CatalogMismatch{
+				ID:            record.ID.Hex(),
+				Collection:    collectionName,
+				Title:         record.Title,
+				CourseCode:    record.CourseCode,
+				Course:        record.Course,
+				Level:         record.Level,
+				Semester:      record.Semester,
+				Session:       record.Session,
+				SuggestedCode: suggested,
+				Issues:        issues,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      len(mismatches),
+		"mismatches": mismatches,
+	})
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetCourseHub returns a course's catalog entry together with its notes,
+// past questions grouped by year, lecturers, download stats and related
+// announcements. The catalog entry comes from ?session= (or the session for
+// ?entryYear=), defaulting to the current one. Retired courses are still
+// served so old links keep working.
+func GetCourseHub(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, ok := sessionFromQuery(c, ctx)
+	if !ok {
+		return
+	}
+
+	course, ok, err := findCourse(ctx, session, c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	hub := models.CourseHub{Course: course}
+
+	// Notes, newest first
+	cursor, err := config.GetCollection("notes").Find(
+		ctx,
+		bson.M{"courseCode": course.Code},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notes"})
+		return
+	}
+	err = cursor.All(ctx, &hub.Notes)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode notes"})
+		return
+	}
+	if hub.Notes == nil {
+		hub.Notes = []models.Note{}
+	}
+
+	// Past questions, grouped by year with the latest year first
+	cursor, err = config.GetCollection("pastquestions").Find(
+		ctx,
+		bson.M{"courseCode": course.Code},
+		options.Find().
+			SetSort(bson.D{{Key: "year", Value: -1}, {Key: "createdAt", Value: -1}}).
+			SetProjection(bson.M{"solutions": 0}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch past questions"})
+		return
+	}
+	var pastQuestions []models.PastQuestion
+	err = cursor.All(ctx, &pastQuestions)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode past questions"})
+		return
+	}
+
+	hub.PastQuestions = []models.PastQuestionYear{}
+	for _, pq := range pastQuestions {
+		last := len(hub.PastQuestions) - 1
+		if last < 0 || hub.PastQuestions[last].Year != pq.Year {
+			hub.PastQuestions = append(hub.PastQuestions, models.PastQuestionYear{Year: pq.Year})
+			last++
+		}
+		hub.PastQuestions[last].PastQuestions = append(hub.PastQuestions[last].PastQuestions, pq)
+	}
+
+	// Lecturers assigned to the course this session, plus any linked on its
+	// notes from other sessions
+	lecturerFilter := []bson.M{{"courses": bson.M{"$elemMatch": bson.M{
+		"session":    course.Session,
+		"courseCode": course.Code,
+	}}}}
+	var linked []primitive.ObjectID
+	for _, note := range hub.Notes {
+		if note.LecturerID != nil {
+			linked = append(linked, *note.LecturerID)
+		}
+	}
+	if len(linked) > 0 {
+		lecturerFilter = append(lecturerFilter, bson.M{"_id": bson.M{"$in": linked}})
+	}
+
+	cursor, err = config.GetCollection("lecturers").Find(
+		ctx,
+		bson.M{"$or": lecturerFilter},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lecturers"})
+		return
+	}
+	err = cursor.All(ctx, &hub.Lecturers)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode lecturers"})
+		return
+	}
+	if hub.Lecturers == nil {
+		hub.Lecturers = []models.Lecturer{}
+	}
+
+	// Download stats
+	noteIDs := make([]primitive.ObjectID, 0, len(hub.Notes))
+	for _, note := range hub.Notes {
+		hub.Downloads.NoteDownloads += note.DownloadCount
+		noteIDs = append(noteIDs, note.ID)
+	}
+	pastQuestionIDs := make([]primitive.ObjectID, 0, len(pastQuestions))
+	for _, pq := range pastQuestions {
+		hub.Downloads.PastQuestionDownloads += pq.DownloadCount
+		pastQuestionIDs = append(pastQuestionIDs, pq.ID)
+	}
+	hub.Downloads.Total = hub.Downloads.NoteDownloads + hub.Downloads.PastQuestionDownloads
+
+	since := time.Now().AddDate(0, 0, -30)
+	recentNotes, err := config.GetCollection("note_downloads").CountDocuments(ctx, bson.M{
+		"noteId":     bson.M{"$in": noteIDs},
+		"downloadAt": bson.M{"$gte": since},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count downloads"})
+		return
+	}
+	recentPastQuestions, err := config.GetCollection("pastquestion_downloads").CountDocuments(ctx, bson.M{
+		"pastQuestionId": bson.M{"$in": pastQuestionIDs},
+		"downloadAt":     bson.M{"$gte": since},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count downloads"})
+		return
+	}
+	hub.Downloads.Last30Days = int(recentNotes + recentPastQuestions)
+
+	// Announcements tagged with this course
+	cursor, err = config.GetCollection("announcements").Find(
+		ctx,
+		bson.M{"courseCodes": course.Code},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(10),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch announcements"})
+		return
+	}
+	err = cursor.All(ctx, &hub.Announcements)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode announcements"})
+		return
+	}
+	if hub.Announcements == nil {
+		hub.Announcements = []models.Announcement{}
+	}
+
+	c.JSON(http.StatusOK, hub)
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type courseCoverage struct {
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	AvailableYears []int   `json:"availableYears"`
+	MissingYears   []int   `json:"missingYears"`
+	NotOffered     []int   `json:"notOfferedYears"` // years the course wasn't in that session's curriculum
+	Coverage       float64 `json:"coverage"`        // percentage of offered years with a past question
+}
+
+type coverageGroup struct {
+	Level          int              `json:"level"`
+	Semester       string           `json:"semester"`
+	Courses        []courseCoverage `json:"courses"`
+	CoursesMissing int              `json:"coursesMissing"` // courses with at least one missing year
+}
+
+// GetPastQuestionCoverage crosses the course catalog with the stored past
+// questions and reports, per level and semester, which years each course has
+// and which are missing. Each year is checked against the curriculum of the
+// session its exams were sat in, and courses are grouped by their latest
+// version in the range. Pass format=csv to download it as a spreadsheet.
+func GetPastQuestionCoverage(c *gin.Context) {
+	toYear := time.Now().Year()
+	fromYear := toYear - 4
+
+	for param, target := range map[string]*int{"fromYear": &fromYear, "toYear": &toYear} {
+		if value := c.Query(param); value != "" {
+			year, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+				return
+			}
+			*target = year
+		}
+	}
+	if fromYear > toYear || toYear-fromYear > 30 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Year range must run forwards and span at most 30 years"})
+		return
+	}
+
+	level := 0
+	if levelStr := c.Query("level"); levelStr != "" {
+		var err error
+		if level, err = strconv.Atoi(levelStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return
+		}
+	}
+	semester := models.NormalizeSemester(c.Query("semester"))
+
+	collection := config.GetCollection("pastquestions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"year": bson.M{"$gte": fromYear, "$lte": toYear}}},
+		{"$group": bson.M{
+			"_id":   "$courseCode",
+			"years": bson.M{"$addToSet": "$year"},
+		}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch past questions"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var found []struct {
+		CourseCode string `bson:"_id"`
+		Years      []int  `bson:"years"`
+	}
+	if err := cursor.All(ctx, &found); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode past questions"})
+		return
+	}
+
+	yearsByCourse := map[string]map[int]bool{}
+	for _, entry := range found {
+		years := map[int]bool{}
+		for _, year := range entry.Years {
+			years[year] = true
+		}
+		yearsByCourse[entry.CourseCode] = years
+	}
+
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+
+	// Work out which years each course was part of the curriculum
+	offeredYears := map[string]map[int]bool{}
+	latest := map[string]models.Course{}
+	for year := fromYear; year <= toYear; year++ {
+		session, ok := snapshot.sessionForExamYear(year)
+		if !ok {
+			continue
+		}
+		for _, course := range snapshot.courses {
+			if course.Session != session.Name || course.Retired {
+				continue
+			}
+			if offeredYears[course.Code] == nil {
+				offeredYears[course.Code] = map[int]bool{}
+			}
+			offeredYears[course.Code][year] = true
+			latest[course.Code] = course
+		}
+	}
+
+	var courses []models.Course
+	for _, course := range latest {
+		if level != 0 && course.Level != level {
+			continue
+		}
+		if semester != "" && course.Semester != semester {
+			continue
+		}
+		courses = append(courses, course)
+	}
+	sort.Slice(courses, func(i, j int) bool { return courses[i].Code < courses[j].Code })
+
+	var groups []coverageGroup
+	groupIndex := map[string]int{}
+
+	for _, course := range courses {
+		coverage := courseCoverage{
+			Code:           course.Code,
+			Name:           course.Name,
+			AvailableYears: []int{},
+			MissingYears:   []int{},
+			NotOffered:     []int{},
+		}
+		for year := fromYear; year <= toYear; year++ {
+			switch {
+			case !offeredYears[course.Code][year]:
+				coverage.NotOffered = append(coverage.NotOffered, year)
+			case yearsByCourse[course.Code][year]:
+				coverage.AvailableYears = append(coverage.AvailableYears, year)
+			default:
+				coverage.MissingYears = append(coverage.MissingYears, year)
+			}
+		}
+		offered := len(coverage.AvailableYears) + len(coverage.MissingYears)
+		coverage.Coverage = float64(len(coverage.AvailableYears)) * 100 / float64(offered)
+
+		key := fmt.Sprintf("%d-%s", course.Level, course.Semester)
+		i, ok := groupIndex[key]
+		if !ok {
+			i = len(groups)
+			groupIndex[key] = i
+			groups = append(groups, coverageGroup{Level: course.Level, Semester: course.Semester})
+		}
+		groups[i].Courses = append(groups[i].Courses, coverage)
+		if len(coverage.MissingYears) > 0 {
+			groups[i].CoursesMissing++
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Level != groups[j].Level {
+			return groups[i].Level < groups[j].Level
+		}
+		return groups[i].Semester < groups[j].Semester
+	})
+
+	if c.Query("format") == "csv" {
+		writeCoverageCSV(c, groups, fromYear, toYear)
+		return
+	}
+
+	if groups == nil {
+		groups = []coverageGroup{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"fromYear": fromYear,
+		"toYear":   toYear,
+		"groups":   groups,
+	})
+}
+
+// writeCoverageCSV writes one row per course with a column for every year,
+// so class reps can see at a glance which papers to collect
+func writeCoverageCSV(c *gin.Context, groups []coverageGroup, fromYear, toYear int) {
+	filename := fmt.Sprintf("past-question-coverage-%d-%d.csv", fromYear, toYear)
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+
+	header := []string{"Level", "Semester", "Course Code", "Course Name"}
+	for year := fromYear; year <= toYear; year++ {
+		header = append(header, strconv.Itoa(year))
+	}
+	header = append(header, "Coverage %", "Missing Years")
+	writer.Write(header)
+
+	for _, group := range groups {
+		for _, course := range group.Courses {
+			available := map[int]bool{}
+			for _, year := range course.AvailableYears {
+				available[year] = true
+			}
+			notOffered := map[int]bool{}
+			for _, year := range course.NotOffered {
+				notOffered[year] = true
+			}
+
+			record := []string{strconv.Itoa(group.Level), group.Semester, course.Code, course.Name}
+			for year := fromYear; year <= toYear; year++ {
+				switch {
+				case notOffered[year]:
+					record = append(record, "-")
+				case available[year]:
+					record = append(record, "yes")
+				default:
+					record = append(record, "MISSING")
+				}
+			}
+
+			missing := make([]string, len(course.MissingYears))
+			for i, year := range course.MissingYears {
+				missing[i] = strconv.Itoa(year)
+			}
+			record = append(record, strconv.FormatFloat(course.Coverage, 'f', 0, 64), strings.Join(missing, " "))
+			writer.Write(record)
+		}
+	}
+
+	writer.Flush()
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"bowen-accounting-backend/config"
@@ -11,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -66,6 +69,14 @@ func CreateElection(c *gin.Context) {
 	}
 
 	election.ID = primitive.NewObjectID()
+	if election.Status == "" {
+		election.Status = models.ElectionUpcoming
+		if election.IsOpen {
+			election.Status = models.ElectionOpen
+		}
+	}
+	election.IsOpen = election.Status == models.ElectionOpen
+	election.StatusHistory = nil
 	election.CreatedAt = time.Now()
 	election.UpdatedAt = time.Now()
 
@@ -79,6 +90,12 @@ func CreateElection(c *gin.Context) {
 		return
 	}
 
+	if election.IsOpen {
+		if err := seedVoterRoll(ctx, election.ID); err != nil {
+			log.Printf("Failed to seed voter roll for election %s: %v", election.ID.Hex(), err)
+		}
+	}
+
 	c.JSON(http.StatusCreated, election)
 }
 
@@ -91,64 +108,58 @@ func CastVote(c *gin.Context) {
 
 	userID, _ := c.Get("userId")
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
-	electionObjectID, _ := primitive.ObjectIDFromHex(req.ElectionID)
+	electionObjectID, err := primitive.ObjectIDFromHex(req.ElectionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID", "code": VoteErrInvalidElectionID})
+		return
+	}
 
-	// Check if election is open
 	electionCollection := config.GetCollection("elections")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	var election models.Election
-	err := electionCollection.FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election)
+	err = electionCollection.FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found", "code": VoteErrElectionNotFound})
 		return
 	}
 
-	if !election.IsOpen {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Election currently closed"})
+	voter, err := findUserByID(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "code": VoteErrVoterNotFound})
 		return
 	}
 
-	// Check if user already voted for this position
-	voteCollection := config.GetCollection("votes")
-
-	var existingVote models.Vote
-	err = voteCollection.FindOne(ctx, bson.M{
-		"electionId": electionObjectID,
-		"positionId": req.PositionID,
-		"userId":     userObjectID,
-	}).Decode(&existingVote)
-
-	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "You have already voted for this position"})
+	// Check the election is open and the voter may vote for this candidate
+	if rejection := checkVoteEligibility(election, voter, req.PositionID, req.CandidateID, time.Now()); rejection != nil {
+		rejection.respond(c)
 		return
 	}
 
-	// Record vote
-	vote := models.Vote{
-		ID:          primitive.NewObjectID(),
-		ElectionID:  electionObjectID,
-		PositionID:  req.PositionID,
-		CandidateID: req.CandidateID,
-		UserID:      userObjectID,
-		VotedAt:     time.Now(),
-	}
-
-	_, err = voteCollection.InsertOne(ctx, vote)
+	// Record vote. The unique index on the voter roll rejects a second vote
+	// for the same position, even from concurrent requests.
+	choice := models.BallotChoice{PositionID: req.PositionID, CandidateID: req.CandidateID}
+	ballot, err := recordVotes(ctx, userObjectID, electionObjectID, []models.BallotChoice{choice})
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already voted for this position", "code": VoteErrAlreadyVoted})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record vote"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Vote recorded successfully"})
+	c.JSON(http.StatusCreated, gin.H{"message": "Vote recorded successfully", "receipt": ballot.Receipt})
 }
 
+// GetUserVotes lists the positions the user has voted for. Votes are
+// anonymous, so this confirms participation without the choices.
 func GetUserVotes(c *gin.Context) {
 	userID, _ := c.Get("userId")
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
-	collection := config.GetCollection("votes")
+	collection := config.GetCollection("voter_roll")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -159,55 +170,25 @@ func GetUserVotes(c *gin.Context) {
 	}
 	defer cursor.Close(ctx)
 
-	var votes []models.Vote
-	if err := cursor.All(ctx, &votes); err != nil {
+	var entries []models.VoterRollEntry
+	if err := cursor.All(ctx, &entries); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode votes"})
 		return
 	}
 
-	c.JSON(http.StatusOK, votes)
-}
-
-func GetElectionResults(c *gin.Context) {
-	id := c.Param("id")
-	electionObjectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID"})
-		return
-	}
-
-	collection := config.GetCollection("votes")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Aggregate votes by position and candidate
-	pipeline := []bson.M{
-		{"$match": bson.M{"electionId": electionObjectID}},
-		{"$group": bson.M{
-			"_id": bson.M{
-				"positionId":  "$positionId",
-				"candidateId": "$candidateId",
-			},
-			"count": bson.M{"$sum": 1},
-		}},
-	}
-
-	cursor, err := collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch results"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var results []bson.M
-	if err := cursor.All(ctx, &results); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode results"})
-		return
+	votes := []models.VotedPosition{}
+	for _, entry := range entries {
+		for _, positionID := range entry.Positions {
+			votes = append(votes, models.VotedPosition{ElectionID: entry.ElectionID, PositionID: positionID})
+		}
 	}
 
-	c.JSON(http.StatusOK, results)
+	c.JSON(http.StatusOK, votes)
 }
 
+// ToggleElection opens or closes an election by hand, overriding its
+// schedule. An election closed this way stays closed; one opened this way
+// still closes at its end date.
 func ToggleElection(c *gin.Context) {
 	id := c.Param("id")
 	electionObjectID, err := primitive.ObjectIDFromHex(id)
@@ -225,18 +206,35 @@ func ToggleElection(c *gin.Context) {
 		return
 	}
 
+	userID, _ := c.Get("userId")
+	adminObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
 	collection := config.GetCollection("elections")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{"_id": electionObjectID},
-		bson.M{"$set": bson.M{
-			"isOpen":    req.IsOpen,
-			"updatedAt": time.Now(),
-		}},
-	)
+	var election models.Election
+	if err := collection.FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	to := models.ElectionClosed
+	if req.IsOpen {
+		to = models.ElectionOpen
+	}
+
+	if election.Status == to {
+		// Nothing to record, but keep isOpen in line with the status
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": electionObjectID}, bson.M{"$set": bson.M{"isOpen": req.IsOpen}})
+	} else {
+		var changed bool
+		changed, err = transitionElection(ctx, electionObjectID, election.Status, to, "manual", &adminObjectID)
+		if err == nil && !changed {
+			c.JSON(http.StatusConflict, gin.H{"error": "Election status changed, please try again"})
+			return
+		}
+	}
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle election"})
@@ -290,21 +288,126 @@ func UpdateElection(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	election.UpdatedAt = time.Now()
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{"_id": electionObjectID},
-		bson.M{"$set": election},
-	)
+	var existing models.Election
+	if err := collection.FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&existing); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
 
+	// Status only changes through the scheduler or ToggleElection, so the
+	// history of transitions stays complete
+	set := bson.M{
+		"title":            election.Title,
+		"description":      election.Description,
+		"electionType":     election.ElectionType,
+		"targetLevel":      election.TargetLevel,
+		"startDate":        election.StartDate,
+		"endDate":          election.EndDate,
+		"nominationStart":  election.NominationStart,
+		"nominationEnd":    election.NominationEnd,
+		"committeeMembers": election.CommitteeMembers,
+		"updatedAt":        time.Now(),
+	}
+	filter := bson.M{"_id": electionObjectID}
+
+	// Positions can only change before voting starts; after that they are
+	// left as they are. Candidates added by approving nominations are kept
+	// even if the edit doesn't include them.
+	if election.Positions != nil && existing.Status != models.ElectionOpen && existing.Status != models.ElectionClosed {
+		nominated, err := nominatedCandidateIDs(ctx, electionObjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update election"})
+			return
+		}
+		set["positions"] = mergePositions(existing.Positions, election.Positions, nominated)
+		// Fail if voting started or a nomination was approved meanwhile
+		filter["status"] = bson.M{"$nin": bson.A{models.ElectionOpen, models.ElectionClosed}}
+		filter["updatedAt"] = existing.UpdatedAt
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": set})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update election"})
 		return
 	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The election changed while it was being saved, please try again"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Election updated successfully"})
 }
 
+// nominatedCandidateIDs returns the candidate IDs added to an election by
+// approving nominations. A nominee's candidate ID is their nomination's ID.
+func nominatedCandidateIDs(ctx context.Context, electionID primitive.ObjectID) (map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := config.GetCollection("nominations").Find(ctx, bson.M{"electionId": electionID, "status": "approved"}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var nominations []models.Nomination
+	if err := cursor.All(ctx, &nominations); err != nil {
+		return nil, err
+	}
+
+	ids := map[string]bool{}
+	for _, nomination := range nominations {
+		ids[nomination.ID.Hex()] = true
+	}
+	return ids, nil
+}
+
+// mergePositions applies an edit to an election's positions. The edit
+// decides the positions and their candidates, except that nominated
+// candidates it leaves out are kept, along with any position that has them.
+func mergePositions(existing, edited []models.Position, nominated map[string]bool) []models.Position {
+	merged := append(make([]models.Position, 0, len(edited)), edited...)
+
+	for _, old := range existing {
+		index := -1
+		for i := range merged {
+			if merged[i].ID == old.ID {
+				index = i
+				break
+			}
+		}
+
+		for _, candidate := range old.Candidates {
+			if !nominated[candidate.ID] {
+				continue
+			}
+			if index == -1 {
+				// The edit dropped a position with nominees; keep it without
+				// the candidates the admin added
+				merged = append(merged, models.Position{
+					ID:                old.ID,
+					Title:             old.Title,
+					Description:       old.Description,
+					Level:             old.Level,
+					MinCandidateLevel: old.MinCandidateLevel,
+				})
+				index = len(merged) - 1
+			}
+			kept := false
+			for _, existingCandidate := range merged[index].Candidates {
+				if existingCandidate.ID == candidate.ID {
+					kept = true
+					break
+				}
+			}
+			if !kept {
+				merged[index].Candidates = append(merged[index].Candidates, candidate)
+			}
+		}
+	}
+	return merged
+}
+
+// DeclareWinner overrides the computed result for a position, e.g. to
+// settle a tie. The justification is kept in the audit log.
 func DeclareWinner(c *gin.Context) {
 	id := c.Param("id")
 	electionObjectID, err := primitive.ObjectIDFromHex(id)
@@ -314,39 +417,97 @@ func DeclareWinner(c *gin.Context) {
 	}
 
 	var req struct {
-		PositionID  string `json:"positionId" binding:"required"`
-		CandidateID string `json:"candidateId" binding:"required"`
+		PositionID    string `json:"positionId" binding:"required"`
+		CandidateID   string `json:"candidateId" binding:"required"`
+		Justification string `json:"justification" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if strings.TrimSpace(req.Justification) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A justification is required to override the result"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	adminObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
 	collection := config.GetCollection("elections")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Update the specific candidate's isWinner field
-	_, err = collection.UpdateOne(
-		ctx,
-		bson.M{
-			"_id":                  electionObjectID,
-			"positions.id":         req.PositionID,
-			"positions.candidates.id": req.CandidateID,
-		},
-		bson.M{"$set": bson.M{
-			"positions.$[pos].candidates.$[cand].isWinner": true,
-			"updatedAt": time.Now(),
-		}},
-		options.Update().SetArrayFilters(options.ArrayFilters{
-			Filters: []interface{}{
-				bson.M{"pos.id": req.PositionID},
-				bson.M{"cand.id": req.CandidateID},
-			},
-		}),
-	)
+	var election models.Election
+	if err := collection.FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+	if !electionClosed(election, time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Winners can only be declared once the election has closed"})
+		return
+	}
 
+	var position *models.Position
+	for i := range election.Positions {
+		if election.Positions[i].ID == req.PositionID {
+			position = &election.Positions[i]
+			break
+		}
+	}
+	if position == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Position not found"})
+		return
+	}
+
+	// Only one winner per position: clear whoever held it before
+	previous := []string{}
+	found := false
+	for i := range position.Candidates {
+		candidate := &position.Candidates[i]
+		if candidate.IsWinner {
+			previous = append(previous, candidate.ID)
+		}
+		candidate.IsWinner = candidate.ID == req.CandidateID
+		found = found || candidate.IsWinner
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Candidate not found for this position"})
+		return
+	}
+
+	changes := map[string]models.FieldChange{
+		"positions." + req.PositionID + ".winner":  {From: previous, To: []string{req.CandidateID}},
+		"positions." + req.PositionID + ".outcome": {From: position.Outcome, To: models.OutcomeOverridden},
+	}
+
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to declare winner"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// The override and its audit entry are written together so an override
+	// is never on record without its justification
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		_, err := collection.UpdateOne(
+			sc,
+			bson.M{"_id": electionObjectID},
+			bson.M{"$set": bson.M{
+				"positions.$[pos].candidates": position.Candidates,
+				"positions.$[pos].outcome":    models.OutcomeOverridden,
+				"updatedAt":                   time.Now(),
+			}},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"pos.id": req.PositionID}},
+			}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return nil, recordAudit(sc, "election", electionObjectID, "declare-winner", adminObjectID, changes, req.Justification)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to declare winner"})
 		return
@@ -354,3 +515,9 @@ func DeclareWinner(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Winner declared successfully"})
 }
+
+// GetElectionHistory lists the audit entries for an election, such as
+// winner overrides
+func GetElectionHistory(c *gin.Context) {
+	getAuditHistory(c, "election")
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func testStudent() models.User {
+	return models.User{
+		ID:        primitive.NewObjectID(),
+		FirstName: "Test",
+		LastName:  "Student",
+		Email:     "student@example.com",
+		Role:      "student",
+		Level:     200,
+	}
+}
+
+func testElection() models.Election {
+	return models.Election{
+		ID:           primitive.NewObjectID(),
+		Title:        "Test Election",
+		Status:       "open",
+		IsOpen:       true,
+		ElectionType: "general",
+		Positions: []models.Position{{
+			ID:         "president",
+			Title:      "President",
+			Candidates: []models.Candidate{{ID: "candidate-1", Name: "Candidate One"}},
+		}},
+	}
+}
+
+// voteRouter serves CastVote as the given user, standing in for the auth
+// middleware
+func voteRouter(user models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/elections/vote", func(c *gin.Context) {
+		c.Set("userId", user.ID.Hex())
+		c.Set("userRole", user.Role)
+		c.Next()
+	}, CastVote)
+	return router
+}
+
+func postVote(router *gin.Engine, election models.Election) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"electionId":%q,"positionId":"president","candidateId":"candidate-1"}`, election.ID.Hex())
+	req := httptest.NewRequest(http.MethodPost, "/elections/vote", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func responseCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var resp struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return resp.Code
+}
+
+// mockDocument converts a model to the document a mocked find returns
+func mockDocument(t *testing.T, v interface{}) bson.D {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return doc
+}
+
+// mockVoteLookups queues the election and voter CastVote loads before
+// recording a vote
+func mockVoteLookups(mt *mtest.T, election models.Election, student models.User) {
+	mt.AddMockResponses(
+		mtest.CreateCursorResponse(0, mt.DB.Name()+".elections", mtest.FirstBatch, mockDocument(mt.T, election)),
+		mtest.CreateCursorResponse(0, mt.DB.Name()+".users", mtest.FirstBatch, mockDocument(mt.T, student)),
+	)
+}
+
+func TestCastVoteRecorded(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("returns 201", func(mt *mtest.T) {
+		config.DB = mt.DB
+		election, student := testElection(), testStudent()
+
+		mockVoteLookups(mt, election, student)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(), // ballot chain head
+			mtest.CreateSuccessResponse(), // voter roll
+			mtest.CreateSuccessResponse(), // votes
+			mtest.CreateCursorResponse(0, mt.DB.Name()+".ballot_chains", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: election.ID}, {Key: "seq", Value: 0}, {Key: "headHash", Value: ""}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}), // move the head
+			mtest.CreateSuccessResponse(), // ballot
+			mtest.CreateSuccessResponse(), // commit
+		)
+
+		w := postVote(voteRouter(student), election)
+		if w.Code != http.StatusCreated {
+			mt.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		var resp struct {
+			Receipt string `json:"receipt"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Receipt == "" {
+			mt.Errorf("response %s has no receipt", w.Body.String())
+		}
+	})
+}
+
+func TestCastVoteDuplicateKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("maps to ALREADY_VOTED", func(mt *mtest.T) {
+		config.DB = mt.DB
+		election, student := testElection(), testStudent()
+
+		mockVoteLookups(mt, election, student)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(), // ballot chain head
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "duplicate key"}),
+		)
+
+		w := postVote(voteRouter(student), election)
+		if w.Code != http.StatusConflict {
+			mt.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+		if code := responseCode(mt.T, w); code != VoteErrAlreadyVoted {
+			mt.Errorf("code = %q, want %q", code, VoteErrAlreadyVoted)
+		}
+
+		// The roll upsert must only match an entry that has none of the
+		// chosen positions, and nothing else may be written after it fails
+		var rollUpdate bson.Raw
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName == "update" && event.Command.Lookup("update").StringValue() == "voter_roll" {
+				rollUpdate = event.Command
+			}
+			if event.CommandName == "insert" && event.Command.Lookup("insert").StringValue() == "votes" {
+				mt.Errorf("votes were inserted after the roll update failed")
+			}
+		}
+		if rollUpdate == nil {
+			mt.Fatalf("no voter_roll update was sent")
+		}
+		updates, err := rollUpdate.Lookup("updates").Array().Values()
+		if err != nil || len(updates) != 1 {
+			mt.Fatalf("voter_roll updates = %v, %v", updates, err)
+		}
+		update := updates[0].Document()
+		if upsert, ok := update.Lookup("upsert").BooleanOK(); !ok || !upsert {
+			mt.Errorf("voter_roll update is not an upsert: %s", update)
+		}
+		nin, err := update.LookupErr("q", "positions", "$nin")
+		if err != nil {
+			mt.Fatalf("voter_roll filter has no positions $nin: %s", update)
+		}
+		positions, _ := nin.Array().Values()
+		if len(positions) != 1 || positions[0].StringValue() != "president" {
+			mt.Errorf("positions $nin = %s, want [president]", nin)
+		}
+	})
+}
+
+// TestCastVoteConcurrent fires many votes for the same student and position
+// at once against a real database and checks that exactly one is counted.
+// It runs when MONGODB_TEST_URI is set; votes are recorded in a
+// transaction, so it must point at a replica set.
+func TestCastVoteConcurrent(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set; point it at a MongoDB replica set to run this test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	config.DB = client.Database(fmt.Sprintf("bowen_test_%d", time.Now().UnixNano()))
+	defer config.DB.Drop(context.Background())
+
+	if err := config.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+
+	election, student := testElection(), testStudent()
+	if _, err := config.GetCollection("users").InsertOne(ctx, student); err != nil {
+		t.Fatalf("insert student: %v", err)
+	}
+	if _, err := config.GetCollection("elections").InsertOne(ctx, election); err != nil {
+		t.Fatalf("insert election: %v", err)
+	}
+
+	router := voteRouter(student)
+	const attempts = 10
+	responses := make([]*httptest.ResponseRecorder, attempts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			responses[i] = postVote(router, election)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for _, w := range responses {
+		switch w.Code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			if code := responseCode(t, w); code != VoteErrAlreadyVoted {
+				t.Errorf("code = %q, want %q", code, VoteErrAlreadyVoted)
+			}
+		default:
+			t.Errorf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if created != 1 {
+		t.Errorf("got %d votes accepted, want 1", created)
+	}
+
+	votes, err := config.GetCollection("votes").CountDocuments(ctx, bson.M{"electionId": election.ID})
+	if err != nil {
+		t.Fatalf("count votes: %v", err)
+	}
+	if votes != 1 {
+		t.Errorf("got %d stored votes, want 1", votes)
+	}
+
+	roll, err := config.GetCollection("voter_roll").CountDocuments(ctx, bson.M{
+		"electionId": election.ID,
+		"userId":     student.ID,
+		"positions":  "president",
+	})
+	if err != nil {
+		t.Fatalf("count voter roll: %v", err)
+	}
+	if roll != 1 {
+		t.Errorf("got %d voter roll entries, want 1", roll)
+	}
+}
+
+func TestMergePositionsKeepsNominees(t *testing.T) {
+	nominee := models.Candidate{ID: "nomination-1", Name: "Nominee"}
+	existing := []models.Position{
+		{ID: "president", Title: "President", Candidates: []models.Candidate{{ID: "candidate-1"}, nominee}},
+		{ID: "secretary", Title: "Secretary", Candidates: []models.Candidate{{ID: "candidate-2"}}},
+		{ID: "treasurer", Title: "Treasurer", Candidates: []models.Candidate{{ID: "candidate-3"}, {ID: "nomination-2"}}},
+	}
+	// The admin renames the presidency, drops candidate-1, and removes the
+	// other two positions
+	edited := []models.Position{{ID: "president", Title: "Student President"}}
+	nominated := map[string]bool{"nomination-1": true, "nomination-2": true}
+
+	merged := mergePositions(existing, edited, nominated)
+
+	if len(merged) != 2 {
+		t.Fatalf("got %d positions, want 2: %+v", len(merged), merged)
+	}
+	if merged[0].Title != "Student President" || len(merged[0].Candidates) != 1 || merged[0].Candidates[0].ID != nominee.ID {
+		t.Errorf("president = %+v, want the new title with only the nominee", merged[0])
+	}
+	if merged[1].ID != "treasurer" || len(merged[1].Candidates) != 1 || merged[1].Candidates[0].ID != "nomination-2" {
+		t.Errorf("treasurer = %+v, want it kept with only its nominee", merged[1])
+	}
+}
+
+func TestCheckBallotChecksElectionFirst(t *testing.T) {
+	election, student := testElection(), testStudent()
+	election.IsOpen = false
+	election.Status = models.ElectionClosed
+
+	// A ballot with nothing to check per choice must still be refused
+	rejection := checkBallot(election, student, nil, time.Now())
+	if rejection == nil || rejection.Code != VoteErrElectionClosed {
+		t.Errorf("rejection = %+v, want %s", rejection, VoteErrElectionClosed)
+	}
+
+	election.IsOpen = true
+	election.Status = models.ElectionOpen
+	election.EndDate = time.Now().Add(-time.Hour)
+	rejection = checkBallot(election, student, nil, time.Now())
+	if rejection == nil || rejection.Code != VoteErrElectionEnded {
+		t.Errorf("rejection = %+v, want %s", rejection, VoteErrElectionEnded)
+	}
+}
+
+func TestCastVoteStaleChainHead(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("fails without writing the ballot", func(mt *mtest.T) {
+		config.DB = mt.DB
+		election, student := testElection(), testStudent()
+
+		mockVoteLookups(mt, election, student)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(), // ballot chain head
+			mtest.CreateSuccessResponse(), // voter roll
+			mtest.CreateSuccessResponse(), // votes
+			mtest.CreateCursorResponse(0, mt.DB.Name()+".ballot_chains", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: election.ID}, {Key: "seq", Value: 0}, {Key: "headHash", Value: ""}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}), // head already moved
+		)
+
+		w := postVote(voteRouter(student), election)
+		if w.Code != http.StatusInternalServerError {
+			mt.Fatalf("status = %d, want %d: %s", w.Code, http.StatusInternalServerError, w.Body.String())
+		}
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName == "insert" && event.Command.Lookup("insert").StringValue() == "ballots" {
+				mt.Errorf("ballot was inserted after the chain head moved")
+			}
+			if event.CommandName == "commitTransaction" {
+				mt.Errorf("transaction was committed after the chain head moved")
+			}
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// transitionElection moves an election from one status to another and
+// records the change in its history. The update only applies while the
+// election is still in the from status, so the scheduler and an admin
+// toggling at the same moment can't both win. Closing an election computes
+// its winners, and reopening it clears them. It reports whether the
+// election was changed.
+func transitionElection(ctx context.Context, electionID primitive.ObjectID, from, to, trigger string, changedBy *primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	result, err := config.GetCollection("elections").UpdateOne(
+		ctx,
+		bson.M{"_id": electionID, "status": from},
+		bson.M{
+			"$set": bson.M{
+				"status":    to,
+				"isOpen":    to == models.ElectionOpen,
+				"updatedAt": now,
+			},
+			"$push": bson.M{"statusHistory": models.StatusTransition{
+				From:      from,
+				To:        to,
+				Trigger:   trigger,
+				ChangedBy: changedBy,
+				At:        now,
+			}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.ModifiedCount == 0 {
+		return false, nil
+	}
+
+	if to == models.ElectionOpen {
+		// Elections closed before statuses were tracked reopen from ""
+		if err := clearElectionResults(ctx, electionID); err != nil {
+			log.Printf("Failed to clear results for election %s: %v", electionID.Hex(), err)
+		}
+		if err := seedVoterRoll(ctx, electionID); err != nil {
+			log.Printf("Failed to seed voter roll for election %s: %v", electionID.Hex(), err)
+		}
+	}
+	if to == models.ElectionClosed {
+		if err := computeElectionWinners(ctx, electionID); err != nil {
+			log.Printf("Failed to compute winners for election %s: %v", electionID.Hex(), err)
+		}
+	}
+	return true, nil
+}
+
+// StartElectionScheduler opens and closes elections at their start and end
+// dates, checking on every interval
+func StartElectionScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runElectionSchedule(time.Now())
+		}
+	}()
+}
+
+// runElectionSchedule opens upcoming elections whose start date has passed
+// and closes any election whose end date has passed. An election an admin
+// closed by hand stays closed, and one opened early stays open until its end
+// date.
+func runElectionSchedule(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cursor, err := config.GetCollection("elections").Find(ctx, bson.M{
+		"status": bson.M{"$in": bson.A{models.ElectionUpcoming, models.ElectionOpen, ""}},
+	})
+	if err != nil {
+		log.Printf("Failed to fetch elections for scheduling: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var elections []models.Election
+	if err := cursor.All(ctx, &elections); err != nil {
+		log.Printf("Failed to decode elections for scheduling: %v", err)
+		return
+	}
+
+	for _, election := range elections {
+		var to string
+		switch {
+		case !election.EndDate.IsZero() && !now.Before(election.EndDate):
+			to = models.ElectionClosed
+		case election.Status != models.ElectionOpen && !election.StartDate.IsZero() && !now.Before(election.StartDate):
+			to = models.ElectionOpen
+		default:
+			continue
+		}
+
+		changed, err := transitionElection(ctx, election.ID, election.Status, to, "schedule", nil)
+		if err != nil {
+			log.Printf("Failed to move election %s to %s: %v", election.ID.Hex(), to, err)
+			continue
+		}
+		if changed {
+			log.Printf("Election %q is now %s", election.Title, to)
+		}
+	}
+}
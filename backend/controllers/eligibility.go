@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned in the "code" field when a vote is rejected, so the
+// frontend can tell the reasons apart without parsing messages
+const (
+	VoteErrInvalidElectionID      = "INVALID_ELECTION_ID"
+	VoteErrElectionNotFound       = "ELECTION_NOT_FOUND"
+	VoteErrVoterNotFound          = "VOTER_NOT_FOUND"
+	VoteErrElectionClosed         = "ELECTION_CLOSED"
+	VoteErrElectionNotStarted     = "ELECTION_NOT_STARTED"
+	VoteErrElectionEnded          = "ELECTION_ENDED"
+	VoteErrNotEligibleForElection = "NOT_ELIGIBLE_FOR_ELECTION"
+	VoteErrPositionNotFound       = "POSITION_NOT_FOUND"
+	VoteErrNotEligibleForPosition = "NOT_ELIGIBLE_FOR_POSITION"
+	VoteErrCandidateNotFound      = "CANDIDATE_NOT_FOUND"
+	VoteErrAlreadyVoted           = "ALREADY_VOTED"
+	VoteErrDuplicatePosition      = "DUPLICATE_POSITION"
+	VoteErrIncompleteBallot       = "INCOMPLETE_BALLOT"
+)
+
+// voteRejection is why a vote can't be accepted
+type voteRejection struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (r *voteRejection) respond(c *gin.Context) {
+	c.JSON(r.Status, gin.H{"error": r.Message, "code": r.Code})
+}
+
+// checkElectionOpen checks that an election is open and within its dates,
+// and that the voter may take part in it
+func checkElectionOpen(election models.Election, voter models.User, now time.Time) *voteRejection {
+	if !election.IsOpen {
+		return &voteRejection{http.StatusForbidden, VoteErrElectionClosed, "Election currently closed"}
+	}
+	if !election.StartDate.IsZero() && now.Before(election.StartDate) {
+		return &voteRejection{http.StatusForbidden, VoteErrElectionNotStarted, "Voting has not started yet"}
+	}
+	if !election.EndDate.IsZero() && now.After(election.EndDate) {
+		return &voteRejection{http.StatusForbidden, VoteErrElectionEnded, "Voting has ended"}
+	}
+	if election.ElectionType == "level-based" && election.TargetLevel != 0 && voter.Level != election.TargetLevel {
+		return &voteRejection{http.StatusForbidden, VoteErrNotEligibleForElection, "This election is only open to students in the target level"}
+	}
+	return nil
+}
+
+// checkVoteEligibility validates a vote against the election's state and
+// dates, the voter's level and the position/candidate pair. It returns nil
+// when the vote may be cast.
+func checkVoteEligibility(election models.Election, voter models.User, positionID, candidateID string, now time.Time) *voteRejection {
+	if rejection := checkElectionOpen(election, voter, now); rejection != nil {
+		return rejection
+	}
+
+	var position *models.Position
+	for i := range election.Positions {
+		if election.Positions[i].ID == positionID {
+			position = &election.Positions[i]
+			break
+		}
+	}
+	if position == nil {
+		return &voteRejection{http.StatusBadRequest, VoteErrPositionNotFound, "Position not found in this election"}
+	}
+	if position.Level != 0 && voter.Level != position.Level {
+		return &voteRejection{http.StatusForbidden, VoteErrNotEligibleForPosition, "This position is only open to students in its level"}
+	}
+
+	for _, candidate := range position.Candidates {
+		if candidate.ID == candidateID {
+			return nil
+		}
+	}
+	return &voteRejection{http.StatusBadRequest, VoteErrCandidateNotFound, "Candidate not found for this position"}
+}
@@ -0,0 +1,466 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func findLecturer(ctx context.Context, id primitive.ObjectID) (models.Lecturer, error) {
+	var lecturer models.Lecturer
+	err := config.GetCollection("lecturers").FindOne(ctx, bson.M{"_id": id}).Decode(&lecturer)
+	return lecturer, err
+}
+
+// loadLecturersByKey indexes every lecturer by the comparison key of their
+// name and each alias
+func loadLecturersByKey(ctx context.Context) (map[string]models.Lecturer, error) {
+	cursor, err := config.GetCollection("lecturers").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var lecturers []models.Lecturer
+	if err := cursor.All(ctx, &lecturers); err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]models.Lecturer{}
+	for _, lecturer := range lecturers {
+		for _, name := range append([]string{lecturer.Name}, lecturer.Aliases...) {
+			if key := models.LecturerNameKey(name); key != "" {
+				byKey[key] = lecturer
+			}
+		}
+	}
+	return byKey, nil
+}
+
+// resolveLecturerRef looks up the lecturer a note or past question is being
+// linked to. On failure it writes the error response and returns false.
+func resolveLecturerRef(c *gin.Context, ctx context.Context, id primitive.ObjectID) (models.Lecturer, bool) {
+	lecturer, err := findLecturer(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown lecturer"})
+			return models.Lecturer{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lecturer"})
+		return models.Lecturer{}, false
+	}
+	return lecturer, true
+}
+
+// normalizeLecturerCourses checks each assignment against its session's
+// catalog and puts the course codes in canonical form
+func normalizeLecturerCourses(ctx context.Context, courses []models.LecturerCourse) ([]models.LecturerCourse, error) {
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := []models.LecturerCourse{}
+	seen := map[models.LecturerCourse]bool{}
+	for _, assignment := range courses {
+		if _, ok := snapshot.session(assignment.Session); !ok {
+			return nil, fmt.Errorf("unknown academic session %q", assignment.Session)
+		}
+		course, ok := snapshot.course(assignment.Session, assignment.CourseCode)
+		if !ok {
+			return nil, fmt.Errorf("unknown course code %q for the %s session", assignment.CourseCode, assignment.Session)
+		}
+		entry := models.LecturerCourse{Session: assignment.Session, CourseCode: course.Code}
+		if !seen[entry] {
+			seen[entry] = true
+			normalized = append(normalized, entry)
+		}
+	}
+	return normalized, nil
+}
+
+func GetLecturers(c *gin.Context) {
+	collection := config.GetCollection("lecturers")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	assignment := bson.M{}
+	if session := c.Query("session"); session != "" {
+		assignment["session"] = session
+	}
+	if courseCode := c.Query("courseCode"); courseCode != "" {
+		assignment["courseCode"] = models.NormalizeCourseCode(courseCode)
+	}
+	if len(assignment) > 0 {
+		filter["courses"] = bson.M{"$elemMatch": assignment}
+	}
+	if search := c.Query("search"); search != "" {
+		pattern := regexp.QuoteMeta(search)
+		filter["$or"] = []bson.M{
+			{"name": bson.M{"$regex": pattern, "$options": "i"}},
+			{"aliases": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lecturers"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var lecturers []models.Lecturer
+	if err := cursor.All(ctx, &lecturers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode lecturers"})
+		return
+	}
+
+	if lecturers == nil {
+		lecturers = []models.Lecturer{}
+	}
+
+	c.JSON(http.StatusOK, lecturers)
+}
+
+func GetLecturer(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lecturer ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lecturer, err := findLecturer(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lecturer not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lecturer)
+}
+
+func CreateLecturer(c *gin.Context) {
+	var req models.CreateLecturerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	courses, err := normalizeLecturerCourses(ctx, req.Courses)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aliases := req.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	lecturer := models.Lecturer{
+		ID:        primitive.NewObjectID(),
+		Name:      strings.TrimSpace(req.Name),
+		Title:     strings.TrimSpace(req.Title),
+		Email:     strings.TrimSpace(req.Email),
+		Office:    strings.TrimSpace(req.Office),
+		Courses:   courses,
+		Aliases:   aliases,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	_, err = config.GetCollection("lecturers").InsertOne(ctx, lecturer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create lecturer"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, lecturer)
+}
+
+// UpdateLecturer edits a lecturer record. A change of name or title is
+// copied onto the notes and past questions linked to them.
+func UpdateLecturer(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lecturer ID"})
+		return
+	}
+
+	var req models.UpdateLecturerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{}
+	if req.Name != nil {
+		set["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Title != nil {
+		set["title"] = strings.TrimSpace(*req.Title)
+	}
+	if req.Email != nil {
+		set["email"] = strings.TrimSpace(*req.Email)
+	}
+	if req.Office != nil {
+		set["office"] = strings.TrimSpace(*req.Office)
+	}
+	if req.Courses != nil {
+		courses, err := normalizeLecturerCourses(ctx, *req.Courses)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		set["courses"] = courses
+	}
+	if req.Aliases != nil {
+		set["aliases"] = *req.Aliases
+	}
+	if len(set) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+	set["updatedAt"] = time.Now()
+
+	var lecturer models.Lecturer
+	err = config.GetCollection("lecturers").FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&lecturer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lecturer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update lecturer"})
+		return
+	}
+
+	if req.Name != nil || req.Title != nil {
+		for _, collectionName := range []string{"notes", "pastquestions"} {
+			_, err := config.GetCollection(collectionName).UpdateMany(
+				ctx,
+				bson.M{"lecturerId": objID},
+				bson.M{"$set": bson.M{"lecturer": lecturer.DisplayName()}},
+			)
+			if err != nil {
+				log.Printf("Failed to update lecturer name on %s for %s: %v", collectionName, objID.Hex(), err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, lecturer)
+}
+
+// DeleteLecturer removes a lecturer record. Linked notes and past questions
+// keep the lecturer's name as free text.
+func DeleteLecturer(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lecturer ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := config.GetCollection("lecturers").DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete lecturer"})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lecturer not found"})
+		return
+	}
+
+	for _, collectionName := range []string{"notes", "pastquestions"} {
+		_, err := config.GetCollection(collectionName).UpdateMany(
+			ctx,
+			bson.M{"lecturerId": objID},
+			bson.M{"$unset": bson.M{"lecturerId": ""}},
+		)
+		if err != nil {
+			log.Printf("Failed to unlink lecturer %s from %s: %v", objID.Hex(), collectionName, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lecturer deleted successfully"})
+}
+
+// LecturerBackfillResult summarises a lecturer backfill run
+type LecturerBackfillResult struct {
+	Spellings   int
+	Matched     int
+	Created     int
+	Skipped     int
+	NotesLinked int64
+}
+
+// BackfillLecturers links notes carrying a free-text lecturer name to
+// lecturer records. Spellings that reduce to the same name key are treated
+// as one person; people with no record get one, named after their most used
+// spelling. With dryRun set nothing is written.
+func BackfillLecturers(ctx context.Context, dryRun bool) (LecturerBackfillResult, error) {
+	var result LecturerBackfillResult
+
+	notes := config.GetCollection("notes")
+	lecturers := config.GetCollection("lecturers")
+
+	byKey, err := loadLecturersByKey(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	cursor, err := notes.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{
+			"lecturerId": bson.M{"$exists": false},
+			"lecturer":   bson.M{"$nin": []interface{}{"", nil}},
+		}},
+		{"$group": bson.M{
+			"_id":   "$lecturer",
+			"count": bson.M{"$sum": 1},
+			"courses": bson.M{"$addToSet": bson.M{
+				"session":    "$session",
+				"courseCode": "$courseCode",
+			}},
+		}},
+		{"$sort": bson.M{"count": -1}},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	var spellings []struct {
+		Name    string                  `bson:"_id"`
+		Count   int                     `bson:"count"`
+		Courses []models.LecturerCourse `bson:"courses"`
+	}
+	err = cursor.All(ctx, &spellings)
+	cursor.Close(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Spellings = len(spellings)
+
+	// Spellings arrive most used first, so the first one per key names any
+	// new record
+	type person struct {
+		names   []string
+		courses []models.LecturerCourse
+	}
+	people := map[string]*person{}
+	var keys []string
+	for _, spelling := range spellings {
+		key := models.LecturerNameKey(spelling.Name)
+		if key == "" {
+			result.Skipped++
+			continue
+		}
+		if people[key] == nil {
+			people[key] = &person{courses: []models.LecturerCourse{}}
+			keys = append(keys, key)
+		}
+		people[key].names = append(people[key].names, spelling.Name)
+		for _, course := range spelling.Courses {
+			if course.Session != "" && course.CourseCode != "" {
+				people[key].courses = append(people[key].courses, course)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		p := people[key]
+
+		lecturer, exists := byKey[key]
+		if exists {
+			result.Matched++
+		} else {
+			title, name := models.SplitLecturerTitle(p.names[0])
+			lecturer = models.Lecturer{
+				ID:        primitive.NewObjectID(),
+				Name:      name,
+				Title:     title,
+				Courses:   []models.LecturerCourse{},
+				Aliases:   []string{},
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			result.Created++
+		}
+
+		if dryRun {
+			count, err := notes.CountDocuments(ctx, bson.M{
+				"lecturerId": bson.M{"$exists": false},
+				"lecturer":   bson.M{"$in": p.names},
+			})
+			if err != nil {
+				return result, err
+			}
+			result.NotesLinked += count
+			continue
+		}
+
+		if !exists {
+			if _, err := lecturers.InsertOne(ctx, lecturer); err != nil {
+				return result, err
+			}
+		}
+
+		_, err := lecturers.UpdateOne(ctx, bson.M{"_id": lecturer.ID}, bson.M{
+			"$addToSet": bson.M{
+				"aliases": bson.M{"$each": p.names},
+				"courses": bson.M{"$each": p.courses},
+			},
+			"$set": bson.M{"updatedAt": time.Now()},
+		})
+		if err != nil {
+			return result, err
+		}
+
+		updated, err := notes.UpdateMany(
+			ctx,
+			bson.M{
+				"lecturerId": bson.M{"$exists": false},
+				"lecturer":   bson.M{"$in": p.names},
+			},
+			bson.M{"$set": bson.M{
+				"lecturerId": lecturer.ID,
+				"lecturer":   lecturer.DisplayName(),
+			}},
+		)
+		if err != nil {
+			return result, err
+		}
+		result.NotesLinked += updated.ModifiedCount
+	}
+
+	return result, nil
+}
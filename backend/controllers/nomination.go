@@ -0,0 +1,417 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errNominationScreened = errors.New("nomination has already been screened")
+	errVotingStarted      = errors.New("voting has already started")
+)
+
+// nominationIssues lists the reasons a student may not stand for a
+// position. An empty list means they are eligible.
+func nominationIssues(election models.Election, position models.Position, user models.User) []string {
+	issues := []string{}
+	if user.Role != "student" {
+		issues = append(issues, "Only students can stand for election")
+	}
+	if election.ElectionType == "level-based" && election.TargetLevel != 0 && user.Level != election.TargetLevel {
+		issues = append(issues, fmt.Sprintf("This election is only open to %d level students", election.TargetLevel))
+	}
+	if position.Level != 0 && user.Level != position.Level {
+		issues = append(issues, fmt.Sprintf("%s is only open to %d level students", position.Title, position.Level))
+	}
+	if position.MinCandidateLevel != 0 && user.Level < position.MinCandidateLevel {
+		issues = append(issues, fmt.Sprintf("%s candidates must be in %d level or above", position.Title, position.MinCandidateLevel))
+	}
+	return issues
+}
+
+// canScreenNominations reports whether the logged-in user is an admin or on
+// the election's committee
+func canScreenNominations(c *gin.Context, election models.Election) bool {
+	if role, _ := c.Get("userRole"); role == "admin" {
+		return true
+	}
+	userID, _ := c.Get("userId")
+	for _, member := range election.CommitteeMembers {
+		if member.Hex() == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// loadElection fetches the election in the :id parameter. On failure it
+// writes the error response and returns false.
+func loadElection(c *gin.Context, ctx context.Context) (models.Election, bool) {
+	var election models.Election
+
+	electionObjectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID"})
+		return election, false
+	}
+
+	if err := config.GetCollection("elections").FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return election, false
+	}
+	return election, true
+}
+
+// SubmitNomination lets a student apply to stand for a position while the
+// election's nomination window is open. A rejected applicant may apply again.
+func SubmitNomination(c *gin.Context) {
+	var req models.CreateNominationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	election, ok := loadElection(c, ctx)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if election.NominationStart.IsZero() || election.NominationEnd.IsZero() ||
+		now.Before(election.NominationStart) || !now.Before(election.NominationEnd) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Nominations are not open for this election"})
+		return
+	}
+
+	var position *models.Position
+	for i := range election.Positions {
+		if election.Positions[i].ID == req.PositionID {
+			position = &election.Positions[i]
+			break
+		}
+	}
+	if position == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Position not found in this election"})
+		return
+	}
+
+	user, err := findUserByID(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if issues := nominationIssues(election, *position, user); len(issues) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": issues[0], "issues": issues})
+		return
+	}
+
+	nomination := models.Nomination{
+		ID:            primitive.NewObjectID(),
+		ElectionID:    election.ID,
+		PositionID:    position.ID,
+		PositionTitle: position.Title,
+		UserID:        user.ID,
+		Name:          strings.TrimSpace(user.FirstName + " " + user.LastName),
+		MatricNumber:  user.MatricNumber,
+		Level:         user.Level,
+		Manifesto:     req.Manifesto,
+		PhotoURL:      req.PhotoURL,
+		Status:        "pending",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	collection := config.GetCollection("nominations")
+
+	// Replace a rejected application rather than keeping both
+	var existing models.Nomination
+	err = collection.FindOne(ctx, bson.M{"electionId": election.ID, "userId": user.ID}).Decode(&existing)
+	switch {
+	case err == nil && existing.Status != "rejected":
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already applied in this election"})
+		return
+	case err == nil:
+		nomination.ID = existing.ID
+		nomination.CreatedAt = existing.CreatedAt
+		_, err = collection.ReplaceOne(ctx, bson.M{"_id": existing.ID, "status": "rejected"}, nomination)
+	case err == mongo.ErrNoDocuments:
+		_, err = collection.InsertOne(ctx, nomination)
+	}
+
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already applied in this election"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit nomination"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, nomination)
+}
+
+func GetMyNominations(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := config.GetCollection("nominations").Find(ctx, bson.M{"userId": userObjectID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch nominations"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var nominations []models.Nomination
+	if err := cursor.All(ctx, &nominations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode nominations"})
+		return
+	}
+
+	if nominations == nil {
+		nominations = []models.Nomination{}
+	}
+
+	c.JSON(http.StatusOK, nominations)
+}
+
+// GetNominations lists an election's applications for the committee,
+// optionally filtered by ?status= and ?positionId=
+func GetNominations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	election, ok := loadElection(c, ctx)
+	if !ok {
+		return
+	}
+	if !canScreenNominations(c, election) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the electoral committee can view nominations"})
+		return
+	}
+
+	filter := bson.M{"electionId": election.ID}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if positionID := c.Query("positionId"); positionID != "" {
+		filter["positionId"] = positionID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := config.GetCollection("nominations").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch nominations"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var nominations []models.Nomination
+	if err := cursor.All(ctx, &nominations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode nominations"})
+		return
+	}
+
+	if nominations == nil {
+		nominations = []models.Nomination{}
+	}
+
+	c.JSON(http.StatusOK, nominations)
+}
+
+// ScreenNomination records the committee's decision on an application.
+// Approving re-checks eligibility and adds the student to the position's
+// candidates in the same transaction, which is only allowed before voting
+// opens. Rejections need a reason.
+func ScreenNomination(c *gin.Context) {
+	nominationObjectID, err := primitive.ObjectIDFromHex(c.Param("nominationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid nomination ID"})
+		return
+	}
+
+	var req models.ScreenNominationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Decision == "rejected" && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required when rejecting a nomination"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	screenerID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	election, ok := loadElection(c, ctx)
+	if !ok {
+		return
+	}
+	if !canScreenNominations(c, election) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the electoral committee can screen nominations"})
+		return
+	}
+
+	collection := config.GetCollection("nominations")
+	var nomination models.Nomination
+	err = collection.FindOne(ctx, bson.M{"_id": nominationObjectID, "electionId": election.ID}).Decode(&nomination)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nomination not found"})
+		return
+	}
+	if nomination.UserID == screenerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't screen your own nomination"})
+		return
+	}
+	if nomination.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Nomination has already been screened"})
+		return
+	}
+
+	var candidate models.Candidate
+	if req.Decision == "approved" {
+		if election.Status == models.ElectionOpen || election.Status == models.ElectionClosed {
+			c.JSON(http.StatusConflict, gin.H{"error": "Candidates can't be added once voting has started"})
+			return
+		}
+
+		var position *models.Position
+		for i := range election.Positions {
+			if election.Positions[i].ID == nomination.PositionID {
+				position = &election.Positions[i]
+				break
+			}
+		}
+		if position == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "The position no longer exists in this election"})
+			return
+		}
+
+		// The student's level may have changed since they applied
+		user, err := findUserByID(ctx, nomination.UserID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if issues := nominationIssues(election, *position, user); len(issues) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": issues[0], "issues": issues})
+			return
+		}
+
+		candidate = models.Candidate{
+			ID:           nomination.ID.Hex(),
+			UserID:       user.ID,
+			Name:         nomination.Name,
+			Level:        user.Level,
+			MatricNumber: nomination.MatricNumber,
+			Manifesto:    nomination.Manifesto,
+			ImageURL:     nomination.PhotoURL,
+		}
+	}
+
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to screen nomination"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	now := time.Now()
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		result, err := collection.UpdateOne(
+			sc,
+			bson.M{"_id": nomination.ID, "status": "pending"},
+			bson.M{"$set": bson.M{
+				"status":     req.Decision,
+				"reason":     req.Reason,
+				"screenedBy": screenerID,
+				"screenedAt": now,
+				"updatedAt":  now,
+			}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errNominationScreened
+		}
+
+		if req.Decision != "approved" {
+			return nil, nil
+		}
+
+		result, err = config.GetCollection("elections").UpdateOne(
+			sc,
+			bson.M{
+				"_id":    election.ID,
+				"status": bson.M{"$nin": bson.A{models.ElectionOpen, models.ElectionClosed}},
+			},
+			bson.M{
+				"$push": bson.M{"positions.$[pos].candidates": candidate},
+				"$set":  bson.M{"updatedAt": now},
+			},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"pos.id": nomination.PositionID}},
+			}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.ModifiedCount == 0 {
+			return nil, errVotingStarted
+		}
+		return nil, nil
+	})
+
+	switch {
+	case errors.Is(err, errNominationScreened):
+		c.JSON(http.StatusConflict, gin.H{"error": "Nomination has already been screened"})
+		return
+	case errors.Is(err, errVotingStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Candidates can't be added once voting has started"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to screen nomination"})
+		return
+	}
+
+	changes := map[string]models.FieldChange{"status": {From: "pending", To: req.Decision}}
+	if err := recordAudit(ctx, "nomination", nomination.ID, req.Decision, screenerID, changes, req.Reason); err != nil {
+		log.Printf("Failed to record audit entry for nomination %s: %v", nomination.ID.Hex(), err)
+	}
+
+	nomination.Status = req.Decision
+	nomination.Reason = req.Reason
+	nomination.ScreenedBy = &screenerID
+	nomination.ScreenedAt = &now
+	nomination.UpdatedAt = now
+	c.JSON(http.StatusOK, nomination)
+}
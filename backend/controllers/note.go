@@ -3,7 +3,9 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"bowen-accounting-backend/config"
@@ -12,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -31,6 +34,9 @@ func GetNotes(c *gin.Context) {
 	if semester := c.Query("semester"); semester != "" {
 		filter["semester"] = semester
 	}
+	if session := c.Query("session"); session != "" {
+		filter["session"] = session
+	}
 	if levelStr := c.Query("level"); levelStr != "" {
 		// Convert level from string to int
 		var level int
@@ -38,6 +44,13 @@ func GetNotes(c *gin.Context) {
 			filter["level"] = level
 		}
 	}
+	if c.Query("mine") == "true" {
+		codes, ok := myCourseCodes(c, ctx)
+		if !ok {
+			return
+		}
+		filter["$and"] = []bson.M{{"courseCode": bson.M{"$in": codes}}}
+	}
 	if search := c.Query("search"); search != "" {
 		filter["$or"] = []bson.M{
 			{"title": bson.M{"$regex": search, "$options": "i"}},
@@ -100,6 +113,43 @@ func CreateNote(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, note.Session, time.Now())
+	if !ok {
+		return
+	}
+
+	course, ok := resolveActiveCourse(c, ctx, session, note.CourseCode)
+	if !ok {
+		return
+	}
+	// Link the lecturer record, recognising free-text names where possible
+	if note.LecturerID != nil {
+		lecturer, ok := resolveLecturerRef(c, ctx, *note.LecturerID)
+		if !ok {
+			return
+		}
+		note.Lecturer = lecturer.DisplayName()
+	} else if strings.TrimSpace(note.Lecturer) != "" {
+		byKey, err := loadLecturersByKey(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lecturers"})
+			return
+		}
+		if lecturer, ok := byKey[models.LecturerNameKey(note.Lecturer)]; ok {
+			note.LecturerID = &lecturer.ID
+			note.Lecturer = lecturer.DisplayName()
+		}
+	}
+
+	note.Session = session
+	note.CourseCode = course.Code
+	note.Course = course.Name
+	note.Level = course.Level
+	note.Semester = course.Semester
+
 	userID, _ := c.Get("userId")
 	uploaderID, _ := primitive.ObjectIDFromHex(userID.(string))
 
@@ -110,15 +160,14 @@ func CreateNote(c *gin.Context) {
 	note.UpdatedAt = time.Now()
 
 	collection := config.GetCollection("notes")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	_, err := collection.InsertOne(ctx, note)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create note"})
 		return
 	}
 
+	generateNoteThumbnailAsync(note)
+
 	c.JSON(http.StatusCreated, note)
 }
 
@@ -170,16 +219,24 @@ func DeleteNote(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	var note models.Note
+	err = collection.FindOneAndDelete(ctx, bson.M{"_id": objectID}).Decode(&note)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Note not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete note"})
 		return
 	}
 
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found"})
-		return
+	// Download history for a note that no longer exists is meaningless
+	downloadCollection := config.GetCollection("note_downloads")
+	if _, err := downloadCollection.DeleteMany(ctx, bson.M{"noteId": objectID}); err != nil {
+		log.Printf("Failed to delete downloads for note %s: %v", id, err)
 	}
 
+	removeStoredFiles("note "+id+" deleted", note.FileURL, note.ThumbnailURL)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Note deleted successfully"})
 }
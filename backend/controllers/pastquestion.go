@@ -2,7 +2,11 @@ package controllers
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"bowen-accounting-backend/config"
@@ -11,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -21,6 +26,34 @@ func CreatePastQuestion(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, req.Session, examDate(req.Year))
+	if !ok {
+		return
+	}
+
+	course, ok := resolveActiveCourse(c, ctx, session, req.CourseCode)
+	if !ok {
+		return
+	}
+
+	var lecturerID *primitive.ObjectID
+	var lecturerName string
+	if req.LecturerID != "" {
+		objID, err := primitive.ObjectIDFromHex(req.LecturerID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lecturer ID"})
+			return
+		}
+		lecturer, ok := resolveLecturerRef(c, ctx, objID)
+		if !ok {
+			return
+		}
+		lecturerID, lecturerName = &lecturer.ID, lecturer.DisplayName()
+	}
+
 	// Get user ID from context (set by auth middleware)
 	userID, _ := c.Get("userId")
 	userIDStr := userID.(string)
@@ -34,11 +67,14 @@ func CreatePastQuestion(c *gin.Context) {
 		ID:          primitive.NewObjectID(),
 		Title:       req.Title,
 		Description: req.Description,
-		Course:      req.Course,
-		CourseCode:  req.CourseCode,
-		Level:       req.Level,
-		Semester:    req.Semester,
+		Course:      course.Name,
+		CourseCode:  course.Code,
+		Level:       course.Level,
+		Semester:    course.Semester,
 		Year:        req.Year,
+		Session:     session,
+		LecturerID:  lecturerID,
+		Lecturer:    lecturerName,
 		FileURL:     req.FileURL,
 		FileName:    req.FileName,
 		UploadedBy:  userObjID,
@@ -47,9 +83,6 @@ func CreatePastQuestion(c *gin.Context) {
 	}
 
 	collection := config.GetCollection("pastquestions")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	_, err = collection.InsertOne(ctx, pastQuestion)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create past question"})
@@ -66,15 +99,99 @@ func GetPastQuestions(c *gin.Context) {
 
 	// Optional filters
 	filter := bson.M{}
-	if level := c.Query("level"); level != "" {
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return
+		}
 		filter["level"] = level
 	}
+	if semester := c.Query("semester"); semester != "" {
+		filter["semester"] = models.NormalizeSemester(semester)
+	}
+	if session := c.Query("session"); session != "" {
+		filter["session"] = session
+	}
+	if courseCode := c.Query("courseCode"); courseCode != "" {
+		filter["courseCode"] = models.NormalizeCourseCode(courseCode)
+	}
 	if course := c.Query("course"); course != "" {
-		filter["course"] = bson.M{"$regex": course, "$options": "i"}
+		filter["course"] = bson.M{"$regex": regexp.QuoteMeta(course), "$options": "i"}
+	}
+
+	yearFilter := bson.M{}
+	for param, operator := range map[string]string{"year": "$eq", "yearFrom": "$gte", "yearTo": "$lte"} {
+		yearStr := c.Query(param)
+		if yearStr == "" {
+			continue
+		}
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return
+		}
+		yearFilter[operator] = year
+	}
+	if len(yearFilter) > 0 {
+		filter["year"] = yearFilter
+	}
+
+	if c.Query("mine") == "true" {
+		codes, ok := myCourseCodes(c, ctx)
+		if !ok {
+			return
+		}
+		filter["$and"] = []bson.M{{"courseCode": bson.M{"$in": codes}}}
+	}
+	if search := c.Query("search"); search != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(search), "$options": "i"}
+		filter["$or"] = []bson.M{
+			{"title": pattern},
+			{"description": pattern},
+			{"course": pattern},
+			{"courseCode": pattern},
+		}
+	}
+
+	// Sort by createdAt (default) or year, newest first unless asked otherwise
+	sortOrder := -1
+	if c.Query("sortOrder") == "asc" {
+		sortOrder = 1
+	}
+	sort := bson.D{{Key: "createdAt", Value: sortOrder}}
+	switch c.DefaultQuery("sortBy", "createdAt") {
+	case "createdAt":
+	case "year":
+		sort = bson.D{{Key: "year", Value: sortOrder}, {Key: "createdAt", Value: -1}}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sortBy must be createdAt or year"})
+		return
+	}
+	// Solutions are served separately so listings stay small
+	opts := options.Find().SetSort(sort).SetProjection(bson.M{"solutions": 0})
+
+	// Pagination is opt-in so existing clients still get the full list
+	if c.Query("page") != "" || c.Query("limit") != "" {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		opts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count past questions"})
+		return
 	}
 
-	// Sort by createdAt descending
-	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
 	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch past questions"})
@@ -92,6 +209,7 @@ func GetPastQuestions(c *gin.Context) {
 		pastQuestions = []models.PastQuestion{}
 	}
 
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
 	c.JSON(http.StatusOK, pastQuestions)
 }
 
@@ -114,10 +232,12 @@ func GetPastQuestion(c *gin.Context) {
 		return
 	}
 
+	pastQuestion.Solutions = verifiedSolutions(pastQuestion.Solutions)
+
 	c.JSON(http.StatusOK, pastQuestion)
 }
 
-func DeletePastQuestion(c *gin.Context) {
+func DownloadPastQuestion(c *gin.Context) {
 	id := c.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -125,20 +245,358 @@ func DeletePastQuestion(c *gin.Context) {
 		return
 	}
 
+	userID, _ := c.Get("userId")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
 	collection := config.GetCollection("pastquestions")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objID})
+	// Increment download count
+	update := bson.M{"$inc": bson.M{"downloadCount": 1}}
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete past question"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update download count"})
 		return
 	}
 
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Past question not found"})
 		return
 	}
 
+	// Record download history
+	downloadCollection := config.GetCollection("pastquestion_downloads")
+	download := models.PastQuestionDownload{
+		ID:             primitive.NewObjectID(),
+		PastQuestionID: objID,
+		UserID:         userObjID,
+		DownloadAt:     time.Now(),
+	}
+	_, _ = downloadCollection.InsertOne(ctx, download)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Download recorded"})
+}
+
+type popularPastQuestion struct {
+	Downloads      int                 `bson:"downloads" json:"downloads"`
+	UniqueStudents int                 `bson:"uniqueStudents" json:"uniqueStudents"`
+	PastQuestion   models.PastQuestion `bson:"pastQuestion" json:"pastQuestion"`
+}
+
+// GetPopularPastQuestions ranks past questions by downloads within a window,
+// by default the last 60 days so it reflects the current exam season
+func GetPopularPastQuestions(c *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -60)
+
+	if days := c.Query("days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+		from = to.AddDate(0, 0, -n)
+	}
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a date in YYYY-MM-DD format"})
+				return
+			}
+			// to is inclusive, so the window runs until the start of the next day
+			if param == "to" {
+				parsed = parsed.AddDate(0, 0, 1)
+			}
+			*target = parsed
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	pastQuestionMatch := bson.M{}
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return
+		}
+		pastQuestionMatch["pastQuestion.level"] = level
+	}
+
+	collection := config.GetCollection("pastquestion_downloads")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"downloadAt": bson.M{"$gte": from, "$lt": to}}},
+		{"$group": bson.M{
+			"_id":       "$pastQuestionId",
+			"downloads": bson.M{"$sum": 1},
+			"students":  bson.M{"$addToSet": "$userId"},
+		}},
+		{"$lookup": bson.M{
+			"from":         "pastquestions",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "pastQuestion",
+		}},
+		{"$unwind": "$pastQuestion"},
+		{"$match": pastQuestionMatch},
+		{"$sort": bson.D{{Key: "downloads", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit},
+		{"$project": bson.M{
+			"_id":            0,
+			"downloads":      1,
+			"uniqueStudents": bson.M{"$size": "$students"},
+			"pastQuestion":   1,
+		}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch popular past questions"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var results []popularPastQuestion
+	if err := cursor.All(ctx, &results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode popular past questions"})
+		return
+	}
+
+	// Solutions are served separately so rankings stay small
+	for i := range results {
+		results[i].PastQuestion.Solutions = nil
+	}
+	if results == nil {
+		results = []popularPastQuestion{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":          from,
+		"to":            to,
+		"pastQuestions": results,
+	})
+}
+
+func UpdatePastQuestion(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	var req models.UpdatePastQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (req.FileURL == nil) != (req.FileName == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fileUrl and fileName must be replaced together"})
+		return
+	}
+	if req.Year != nil && *req.Year <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+		return
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title can't be empty"})
+		return
+	}
+	// An empty file would also delete the stored one, leaving no file at all
+	if req.FileURL != nil && (strings.TrimSpace(*req.FileURL) == "" || strings.TrimSpace(*req.FileName) == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fileUrl and fileName can't be empty"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	collection := config.GetCollection("pastquestions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing models.PastQuestion
+	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&existing)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Past question not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch past question"})
+		return
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	changes := map[string]models.FieldChange{}
+	setField := func(field string, from, to interface{}) {
+		if from != to {
+			set[field] = to
+			changes[field] = models.FieldChange{From: from, To: to}
+		}
+	}
+
+	if req.Title != nil {
+		setField("title", existing.Title, *req.Title)
+	}
+	if req.Description != nil {
+		setField("description", existing.Description, *req.Description)
+	}
+	if req.Year != nil {
+		setField("year", existing.Year, *req.Year)
+	}
+
+	// A new year moves the paper to that year's session unless one is given;
+	// either way the course is looked up again in the session's catalog
+	session := existing.Session
+	if req.Session != nil || req.Year != nil {
+		requested := ""
+		if req.Session != nil {
+			requested = *req.Session
+		}
+		year := existing.Year
+		if req.Year != nil {
+			year = *req.Year
+		}
+		var ok bool
+		if session, ok = resolveSession(c, ctx, requested, examDate(year)); !ok {
+			return
+		}
+		setField("session", existing.Session, session)
+	}
+	if req.CourseCode != nil || session != existing.Session {
+		code := existing.CourseCode
+		if req.CourseCode != nil {
+			code = *req.CourseCode
+		}
+		course, ok := resolveActiveCourse(c, ctx, session, code)
+		if !ok {
+			return
+		}
+		setField("courseCode", existing.CourseCode, course.Code)
+		setField("course", existing.Course, course.Name)
+		setField("level", existing.Level, course.Level)
+		setField("semester", existing.Semester, course.Semester)
+	}
+	if req.LecturerID != nil {
+		var from, to interface{}
+		if existing.LecturerID != nil {
+			from = existing.LecturerID.Hex()
+		}
+		if *req.LecturerID == "" {
+			if from != nil {
+				unset["lecturerId"] = ""
+				unset["lecturer"] = ""
+				changes["lecturerId"] = models.FieldChange{From: from, To: nil}
+			}
+		} else {
+			objID, err := primitive.ObjectIDFromHex(*req.LecturerID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lecturer ID"})
+				return
+			}
+			lecturer, ok := resolveLecturerRef(c, ctx, objID)
+			if !ok {
+				return
+			}
+			if to = lecturer.ID.Hex(); from != to {
+				set["lecturerId"] = lecturer.ID
+				set["lecturer"] = lecturer.DisplayName()
+				changes["lecturerId"] = models.FieldChange{From: from, To: to}
+			}
+		}
+	}
+	if req.FileURL != nil {
+		setField("fileUrl", existing.FileURL, *req.FileURL)
+		setField("fileName", existing.FileName, *req.FileName)
+	}
+
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, existing)
+		return
+	}
+	set["updatedAt"] = time.Now()
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var updated models.PastQuestion
+	err = collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update past question"})
+		return
+	}
+
+	if err := recordAudit(ctx, "pastquestion", objID, "update", userObjID, changes, ""); err != nil {
+		log.Printf("Failed to record audit entry for past question %s: %v", id, err)
+	}
+
+	if _, replaced := changes["fileUrl"]; replaced {
+		removeStoredFiles("past question "+id+" file replaced", existing.FileURL)
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func GetPastQuestionHistory(c *gin.Context) {
+	getAuditHistory(c, "pastquestion")
+}
+
+func DeletePastQuestion(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	collection := config.GetCollection("pastquestions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var pastQuestion models.PastQuestion
+	err = collection.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&pastQuestion)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Past question not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete past question"})
+		return
+	}
+
+	downloadCollection := config.GetCollection("pastquestion_downloads")
+	if _, err := downloadCollection.DeleteMany(ctx, bson.M{"pastQuestionId": objID}); err != nil {
+		log.Printf("Failed to delete downloads for past question %s: %v", id, err)
+	}
+
+	commentCollection := config.GetCollection("pastquestion_comments")
+	if _, err := commentCollection.DeleteMany(ctx, bson.M{"pastQuestionId": objID}); err != nil {
+		log.Printf("Failed to delete comments for past question %s: %v", id, err)
+	}
+
+	fileURLs := []string{pastQuestion.FileURL}
+	for _, solution := range pastQuestion.Solutions {
+		fileURLs = append(fileURLs, solutionFileURL(solution))
+	}
+	removeStoredFiles("past question "+id+" deleted", fileURLs...)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Past question deleted successfully"})
 }
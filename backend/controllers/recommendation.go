@@ -0,0 +1,515 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxRelatedNotes         = 20
+	maxRelatedPastQuestions = 10
+	maxNotesPerUser         = 200 // caps the pairwise work for heavy downloaders
+	levelBoost              = 1.5
+	semesterBoost           = 1.25
+)
+
+// currentSemester guesses the running semester from the calendar: first
+// semester runs September to January, second semester February to August
+func currentSemester(now time.Time) string {
+	switch now.Month() {
+	case time.September, time.October, time.November, time.December, time.January:
+		return "first"
+	default:
+		return "second"
+	}
+}
+
+type recommendationItem struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	CourseCode string             `bson:"courseCode"`
+	Level      int                `bson:"level"`
+	Semester   string             `bson:"semester"`
+	Year       int                `bson:"year"`
+}
+
+// StartRecommendationWorker recomputes recommendations now and then on every
+// interval
+func StartRecommendationWorker(interval time.Duration) {
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			if err := computeRecommendations(ctx); err != nil {
+				log.Printf("Failed to compute recommendations: %v", err)
+			}
+			cancel()
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// computeRecommendations builds note-to-note similarities from co-downloads
+// and stores a "students also downloaded" list per note plus a "for you" feed
+// per student
+func computeRecommendations(ctx context.Context) error {
+	startedAt := time.Now()
+
+	notes, err := loadRecommendationItems(ctx, "notes")
+	if err != nil {
+		return err
+	}
+	pastQuestions, err := loadRecommendationItems(ctx, "pastquestions")
+	if err != nil {
+		return err
+	}
+
+	// Newest past questions first within each course
+	pastQuestionsByCourse := map[string][]recommendationItem{}
+	for _, pq := range pastQuestions {
+		pastQuestionsByCourse[pq.CourseCode] = append(pastQuestionsByCourse[pq.CourseCode], pq)
+	}
+	for code := range pastQuestionsByCourse {
+		list := pastQuestionsByCourse[code]
+		sort.Slice(list, func(i, j int) bool { return list[i].Year > list[j].Year })
+	}
+
+	userNotes, err := loadUserDownloads(ctx, notes)
+	if err != nil {
+		return err
+	}
+
+	// Count distinct downloaders per note and co-downloads per note pair
+	downloaders := map[primitive.ObjectID]int{}
+	coDownloads := map[primitive.ObjectID]map[primitive.ObjectID]int{}
+	for _, downloaded := range userNotes {
+		ids := make([]primitive.ObjectID, 0, len(downloaded))
+		for id := range downloaded {
+			ids = append(ids, id)
+		}
+		if len(ids) > maxNotesPerUser {
+			ids = ids[:maxNotesPerUser]
+		}
+
+		for i, a := range ids {
+			downloaders[a]++
+			for _, b := range ids[i+1:] {
+				if coDownloads[a] == nil {
+					coDownloads[a] = map[primitive.ObjectID]int{}
+				}
+				if coDownloads[b] == nil {
+					coDownloads[b] = map[primitive.ObjectID]int{}
+				}
+				coDownloads[a][b]++
+				coDownloads[b][a]++
+			}
+		}
+	}
+
+	// Cosine similarity between download sets
+	related := map[primitive.ObjectID][]models.ScoredItem{}
+	for a, others := range coDownloads {
+		items := make([]models.ScoredItem, 0, len(others))
+		for b, count := range others {
+			score := float64(count) / math.Sqrt(float64(downloaders[a]*downloaders[b]))
+			items = append(items, models.ScoredItem{ID: b, Score: score})
+		}
+		related[a] = topScoredItems(items, maxRelatedNotes)
+	}
+
+	noteDocs := make([]mongo.WriteModel, 0, len(related))
+	for noteID, items := range related {
+		courseScores := map[string]float64{notes[noteID].CourseCode: 1}
+		for _, item := range items {
+			courseScores[notes[item.ID].CourseCode] += item.Score
+		}
+
+		doc := models.NoteRecommendation{
+			ID:            noteID,
+			Notes:         items,
+			PastQuestions: pastQuestionsForCourses(courseScores, pastQuestionsByCourse, 0),
+			UpdatedAt:     startedAt,
+		}
+		noteDocs = append(noteDocs, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": noteID}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	if err := replaceRecommendations(ctx, "note_recommendations", noteDocs, startedAt); err != nil {
+		return err
+	}
+
+	levels, err := loadStudentLevels(ctx)
+	if err != nil {
+		return err
+	}
+
+	semester := currentSemester(startedAt)
+	userDocs := make([]mongo.WriteModel, 0, len(userNotes))
+	for userID, downloaded := range userNotes {
+		level := levels[userID]
+
+		scores := map[primitive.ObjectID]float64{}
+		for noteID := range downloaded {
+			for _, item := range related[noteID] {
+				if _, seen := downloaded[item.ID]; !seen {
+					scores[item.ID] += item.Score
+				}
+			}
+		}
+
+		items := make([]models.ScoredItem, 0, len(scores))
+		for noteID, score := range scores {
+			meta := notes[noteID]
+			if level != 0 && meta.Level == level {
+				score *= levelBoost
+			}
+			if meta.Semester == semester {
+				score *= semesterBoost
+			}
+			items = append(items, models.ScoredItem{ID: noteID, Score: score})
+		}
+		items = topScoredItems(items, maxRelatedNotes)
+
+		courseScores := map[string]float64{}
+		for noteID := range downloaded {
+			courseScores[notes[noteID].CourseCode] += 1
+		}
+		for _, item := range items {
+			courseScores[notes[item.ID].CourseCode] += item.Score
+		}
+
+		doc := models.UserRecommendation{
+			ID:            userID,
+			Notes:         items,
+			PastQuestions: pastQuestionsForCourses(courseScores, pastQuestionsByCourse, level),
+			UpdatedAt:     startedAt,
+		}
+		userDocs = append(userDocs, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": userID}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	if err := replaceRecommendations(ctx, "user_recommendations", userDocs, startedAt); err != nil {
+		return err
+	}
+
+	log.Printf("Computed recommendations for %d notes and %d students in %s",
+		len(noteDocs), len(userDocs), time.Since(startedAt).Round(time.Millisecond))
+	return nil
+}
+
+func loadRecommendationItems(ctx context.Context, collectionName string) (map[primitive.ObjectID]recommendationItem, error) {
+	opts := options.Find().SetProjection(bson.M{"courseCode": 1, "level": 1, "semester": 1, "year": 1})
+	cursor, err := config.GetCollection(collectionName).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []recommendationItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]recommendationItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	return byID, nil
+}
+
+// loadUserDownloads returns the set of existing notes each user downloaded
+func loadUserDownloads(ctx context.Context, notes map[primitive.ObjectID]recommendationItem) (map[primitive.ObjectID]map[primitive.ObjectID]struct{}, error) {
+	opts := options.Find().SetProjection(bson.M{"noteId": 1, "userId": 1})
+	cursor, err := config.GetCollection("note_downloads").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	userNotes := map[primitive.ObjectID]map[primitive.ObjectID]struct{}{}
+	for cursor.Next(ctx) {
+		var download models.NoteDownload
+		if err := cursor.Decode(&download); err != nil {
+			return nil, err
+		}
+		if _, ok := notes[download.NoteID]; !ok {
+			continue
+		}
+		if userNotes[download.UserID] == nil {
+			userNotes[download.UserID] = map[primitive.ObjectID]struct{}{}
+		}
+		userNotes[download.UserID][download.NoteID] = struct{}{}
+	}
+
+	return userNotes, cursor.Err()
+}
+
+func loadStudentLevels(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	opts := options.Find().SetProjection(bson.M{"level": 1})
+	cursor, err := config.GetCollection("users").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	levels := make(map[primitive.ObjectID]int, len(users))
+	for _, user := range users {
+		levels[user.ID] = user.Level
+	}
+	return levels, nil
+}
+
+// pastQuestionsForCourses ranks past questions by the score of their course,
+// preferring the given level when it is set
+func pastQuestionsForCourses(courseScores map[string]float64, byCourse map[string][]recommendationItem, level int) []models.ScoredItem {
+	var items []models.ScoredItem
+	for code, score := range courseScores {
+		for _, pq := range byCourse[code] {
+			pqScore := score
+			if level != 0 && pq.Level == level {
+				pqScore *= levelBoost
+			}
+			items = append(items, models.ScoredItem{ID: pq.ID, Score: pqScore})
+		}
+	}
+	return topScoredItems(items, maxRelatedPastQuestions)
+}
+
+func topScoredItems(items []models.ScoredItem, limit int) []models.ScoredItem {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []models.ScoredItem{}
+	}
+	return items
+}
+
+// replaceRecommendations upserts the freshly computed documents and drops any
+// left over from earlier runs
+func replaceRecommendations(ctx context.Context, collectionName string, docs []mongo.WriteModel, startedAt time.Time) error {
+	collection := config.GetCollection(collectionName)
+
+	if len(docs) > 0 {
+		if _, err := collection.BulkWrite(ctx, docs, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+
+	_, err := collection.DeleteMany(ctx, bson.M{"updatedAt": bson.M{"$lt": startedAt}})
+	return err
+}
+
+// findNotesByScore fetches the scored notes in score order
+func findNotesByScore(ctx context.Context, items []models.ScoredItem) ([]models.Note, error) {
+	notes := []models.Note{}
+	if len(items) == 0 {
+		return notes, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	cursor, err := config.GetCollection("notes").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Note
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]models.Note, len(found))
+	for _, note := range found {
+		byID[note.ID] = note
+	}
+	for _, id := range ids {
+		if note, ok := byID[id]; ok {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+// findPastQuestionsByScore fetches the scored past questions in score order
+func findPastQuestionsByScore(ctx context.Context, items []models.ScoredItem) ([]models.PastQuestion, error) {
+	pastQuestions := []models.PastQuestion{}
+	if len(items) == 0 {
+		return pastQuestions, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	opts := options.Find().SetProjection(bson.M{"solutions": 0})
+	cursor, err := config.GetCollection("pastquestions").Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.PastQuestion
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]models.PastQuestion, len(found))
+	for _, pq := range found {
+		byID[pq.ID] = pq
+	}
+	for _, id := range ids {
+		if pq, ok := byID[id]; ok {
+			pastQuestions = append(pastQuestions, pq)
+		}
+	}
+	return pastQuestions, nil
+}
+
+// GetAlsoDownloaded returns the notes and past questions most often
+// downloaded by students who downloaded the given note
+func GetAlsoDownloaded(c *gin.Context) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	collection := config.GetCollection("note_recommendations")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var recommendation models.NoteRecommendation
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&recommendation)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations"})
+		return
+	}
+
+	notes, err := findNotesByScore(ctx, recommendation.Notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended notes"})
+		return
+	}
+
+	pastQuestions, err := findPastQuestionsByScore(ctx, recommendation.PastQuestions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended past questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notes":         notes,
+		"pastQuestions": pastQuestions,
+		"generatedAt":   recommendation.UpdatedAt,
+	})
+}
+
+// GetForYou returns the student's personalised feed. Students without any
+// download history get the most downloaded material for their level and the
+// current semester instead.
+func GetForYou(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var recommendation models.UserRecommendation
+	err := config.GetCollection("user_recommendations").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&recommendation)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations"})
+		return
+	}
+
+	notes, err := findNotesByScore(ctx, recommendation.Notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended notes"})
+		return
+	}
+
+	pastQuestions, err := findPastQuestionsByScore(ctx, recommendation.PastQuestions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended past questions"})
+		return
+	}
+
+	if len(notes) == 0 || len(pastQuestions) == 0 {
+		var user models.User
+		if err := config.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjectID}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		filter := bson.M{"level": user.Level, "semester": currentSemester(time.Now())}
+
+		if len(notes) == 0 {
+			opts := options.Find().SetSort(bson.D{{Key: "downloadCount", Value: -1}}).SetLimit(maxRelatedNotes)
+			cursor, err := config.GetCollection("notes").Find(ctx, filter, opts)
+			if err == nil {
+				cursor.All(ctx, &notes)
+			}
+		}
+
+		if len(pastQuestions) == 0 {
+			opts := options.Find().SetSort(bson.D{{Key: "year", Value: -1}}).SetLimit(maxRelatedPastQuestions).
+				SetProjection(bson.M{"solutions": 0})
+			cursor, err := config.GetCollection("pastquestions").Find(ctx, filter, opts)
+			if err == nil {
+				cursor.All(ctx, &pastQuestions)
+			}
+		}
+	}
+
+	if notes == nil {
+		notes = []models.Note{}
+	}
+	if pastQuestions == nil {
+		pastQuestions = []models.PastQuestion{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notes":         notes,
+		"pastQuestions": pastQuestions,
+		"generatedAt":   recommendation.UpdatedAt,
+	})
+}
+
+// RefreshRecommendations recomputes recommendations immediately
+func RefreshRecommendations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := computeRecommendations(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute recommendations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recommendations refreshed successfully"})
+}
@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// registrationScope is who is registering, for which semester, and which
+// curriculum they choose from
+type registrationScope struct {
+	user           models.User
+	session        string
+	semester       string
+	catalogSession string
+}
+
+// resolveRegistrationScope works out the registration scope for the
+// logged-in student. The semester defaults to the current one. On failure it
+// writes the error response and returns false.
+func resolveRegistrationScope(c *gin.Context, ctx context.Context, semester string) (registrationScope, bool) {
+	var scope registrationScope
+
+	userID, _ := c.Get("userId")
+	objectID, _ := primitive.ObjectIDFromHex(userID.(string))
+	user, err := findUserByID(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return scope, false
+	}
+	scope.user = user
+
+	scope.semester = currentSemester(time.Now())
+	if semester != "" {
+		scope.semester = models.NormalizeSemester(semester)
+	}
+	if scope.semester != "first" && scope.semester != "second" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Semester must be first or second"})
+		return scope, false
+	}
+
+	var ok bool
+	if scope.session, ok = resolveSession(c, ctx, "", time.Now()); !ok {
+		return scope, false
+	}
+
+	entryYear := user.EntryYear
+	if entryYear == 0 {
+		entryYear = models.EstimateEntryYear(user.Level, time.Now())
+	}
+	if scope.catalogSession, ok = sessionForEntryYear(c, ctx, entryYear); !ok {
+		return scope, false
+	}
+
+	return scope, true
+}
+
+// registrationOptions splits the student's curriculum for the semester into
+// courses at their level and lower-level courses they may carry over
+func registrationOptions(ctx context.Context, scope registrationScope) (regular, carryOvers []models.Course, err error) {
+	courses, err := listCourses(ctx, scope.catalogSession, 0, scope.semester, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	regular, carryOvers = []models.Course{}, []models.Course{}
+	for _, course := range courses {
+		switch {
+		case course.Level == scope.user.Level:
+			regular = append(regular, course)
+		case course.Level < scope.user.Level:
+			carryOvers = append(carryOvers, course)
+		}
+	}
+	return regular, carryOvers, nil
+}
+
+// GetRegistrationOptions lists the courses the student can register for
+func GetRegistrationOptions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scope, ok := resolveRegistrationScope(c, ctx, c.Query("semester"))
+	if !ok {
+		return
+	}
+
+	regular, carryOvers, err := registrationOptions(ctx, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":        scope.session,
+		"semester":       scope.semester,
+		"level":          scope.user.Level,
+		"catalogSession": scope.catalogSession,
+		"courses":        regular,
+		"carryOvers":     carryOvers,
+	})
+}
+
+func GetMyRegistration(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scope, ok := resolveRegistrationScope(c, ctx, c.Query("semester"))
+	if !ok {
+		return
+	}
+
+	var registration models.CourseRegistration
+	err := config.GetCollection("registrations").FindOne(ctx, bson.M{
+		"userId":   scope.user.ID,
+		"session":  scope.session,
+		"semester": scope.semester,
+	}).Decode(&registration)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "You haven't registered for this semester"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, registration)
+}
+
+// RegisterCourses sets the student's courses for the semester, replacing
+// any earlier registration
+func RegisterCourses(c *gin.Context) {
+	var req models.RegisterCoursesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scope, ok := resolveRegistrationScope(c, ctx, req.Semester)
+	if !ok {
+		return
+	}
+
+	regular, carryOvers, err := registrationOptions(ctx, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+
+	allowed := map[string]models.RegisteredCourse{}
+	for _, course := range regular {
+		allowed[course.Code] = models.RegisteredCourse{CourseCode: course.Code, Name: course.Name, Level: course.Level}
+	}
+	for _, course := range carryOvers {
+		allowed[course.Code] = models.RegisteredCourse{CourseCode: course.Code, Name: course.Name, Level: course.Level, CarryOver: true}
+	}
+
+	courses := []models.RegisteredCourse{}
+	seen := map[string]bool{}
+	for _, code := range req.CourseCodes {
+		course, ok := allowed[models.NormalizeCourseCode(code)]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": code + " is not available to you this semester"})
+			return
+		}
+		if !seen[course.CourseCode] {
+			seen[course.CourseCode] = true
+			courses = append(courses, course)
+		}
+	}
+
+	var registration models.CourseRegistration
+	err = config.GetCollection("registrations").FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"userId":   scope.user.ID,
+			"session":  scope.session,
+			"semester": scope.semester,
+		},
+		bson.M{
+			"$set": bson.M{
+				"level":          scope.user.Level,
+				"catalogSession": scope.catalogSession,
+				"courses":        courses,
+				"updatedAt":      time.Now(),
+			},
+			"$setOnInsert": bson.M{"createdAt": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&registration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, registration)
+}
+
+// GetCourseEnrolment counts registered students per course for a session
+// and semester (defaults: current), optionally for one level
+func GetCourseEnrolment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, ok := resolveSession(c, ctx, c.Query("session"), time.Now())
+	if !ok {
+		return
+	}
+	semester := currentSemester(time.Now())
+	if value := c.Query("semester"); value != "" {
+		semester = models.NormalizeSemester(value)
+	}
+
+	courseMatch := bson.M{}
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return
+		}
+		courseMatch["courses.level"] = level
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"session": session, "semester": semester}},
+		{"$unwind": "$courses"},
+		{"$match": courseMatch},
+		{"$group": bson.M{
+			"_id":        "$courses.courseCode",
+			"name":       bson.M{"$first": "$courses.name"},
+			"level":      bson.M{"$first": "$courses.level"},
+			"students":   bson.M{"$sum": 1},
+			"carryOvers": bson.M{"$sum": bson.M{"$cond": bson.A{"$courses.carryOver", 1, 0}}},
+		}},
+		{"$sort": bson.D{{Key: "level", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+
+	cursor, err := config.GetCollection("registrations").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch enrolment"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var enrolment []models.CourseEnrolment
+	if err := cursor.All(ctx, &enrolment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode enrolment"})
+		return
+	}
+
+	if enrolment == nil {
+		enrolment = []models.CourseEnrolment{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":  session,
+		"semester": semester,
+		"courses":  enrolment,
+	})
+}
+
+// myCourseCodes returns the codes the logged-in user registered for in the
+// current session, for listings filtered with ?mine=true. On failure it
+// writes the error response and returns false.
+func myCourseCodes(c *gin.Context, ctx context.Context) ([]string, bool) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Log in to see your courses"})
+		return nil, false
+	}
+	objectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	session, ok := resolveSession(c, ctx, "", time.Now())
+	if !ok {
+		return nil, false
+	}
+
+	codes, err := registeredCourseCodes(ctx, objectID, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch registrations"})
+		return nil, false
+	}
+	return codes, true
+}
+
+// registeredCourseCodes returns the codes a user registered for in either
+// semester of a session
+func registeredCourseCodes(ctx context.Context, userID primitive.ObjectID, session string) ([]string, error) {
+	cursor, err := config.GetCollection("registrations").Find(ctx, bson.M{
+		"userId":  userID,
+		"session": session,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var registrations []models.CourseRegistration
+	if err := cursor.All(ctx, &registrations); err != nil {
+		return nil, err
+	}
+
+	codes := []string{}
+	for _, registration := range registrations {
+		for _, course := range registration.Courses {
+			codes = append(codes, course.CourseCode)
+		}
+	}
+	return codes, nil
+}
@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// electionClosed reports whether voting is over. Elections closed by hand
+// before statuses were tracked only have isOpen false and a past end date.
+func electionClosed(election models.Election, now time.Time) bool {
+	if election.Status == models.ElectionClosed {
+		return true
+	}
+	return !election.IsOpen && !election.EndDate.IsZero() && !now.Before(election.EndDate)
+}
+
+// percentage returns part as a percentage of whole, to one decimal place
+func percentage(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
+
+// studentsByLevel counts student accounts per level
+func studentsByLevel(ctx context.Context) (map[int]int64, error) {
+	cursor, err := config.GetCollection("users").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"role": "student"}},
+		{"$group": bson.M{"_id": "$level", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Level int   `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	counts := map[int]int64{}
+	for _, group := range groups {
+		counts[group.Level] = group.Count
+	}
+	return counts, nil
+}
+
+// countByPosition runs an aggregation that groups into {_id: positionId,
+// count} documents and returns the counts keyed by position
+func countByPosition(ctx context.Context, collection string, pipeline []bson.M) (map[string]int64, error) {
+	cursor, err := config.GetCollection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		PositionID string `bson:"_id"`
+		Count      int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{}
+	for _, group := range groups {
+		counts[group.PositionID] = group.Count
+	}
+	return counts, nil
+}
+
+// eligibleVoters counts the students who may vote at the given level, where
+// 0 means any level
+func eligibleVoters(byLevel map[int]int64, level int) int64 {
+	if level != 0 {
+		return byLevel[level]
+	}
+	var total int64
+	for _, count := range byLevel {
+		total += count
+	}
+	return total
+}
+
+// voteTallies counts an election's votes by position ID and candidate ID
+func voteTallies(ctx context.Context, electionID primitive.ObjectID) (map[string]map[string]int64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"electionId": electionID}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"positionId":  "$positionId",
+				"candidateId": "$candidateId",
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := config.GetCollection("votes").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tallies []struct {
+		ID struct {
+			PositionID  string `bson:"positionId"`
+			CandidateID string `bson:"candidateId"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &tallies); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]map[string]int64{}
+	for _, tally := range tallies {
+		if counts[tally.ID.PositionID] == nil {
+			counts[tally.ID.PositionID] = map[string]int64{}
+		}
+		counts[tally.ID.PositionID][tally.ID.CandidateID] = tally.Count
+	}
+	return counts, nil
+}
+
+// decidePosition fills in each candidate's vote count and marks the winner.
+// A shared top count is a tie and no one is marked; the outcome says which.
+// Positions an admin has already decided keep their winner.
+func decidePosition(position *models.Position, counts map[string]int64) {
+	var top int64
+	leaders := 0
+	for i := range position.Candidates {
+		candidate := &position.Candidates[i]
+		candidate.VoteCount = int(counts[candidate.ID])
+
+		switch votes := int64(candidate.VoteCount); {
+		case votes > top:
+			top, leaders = votes, 1
+		case votes == top:
+			leaders++
+		}
+	}
+
+	if position.Outcome == models.OutcomeOverridden {
+		return
+	}
+
+	switch {
+	case top == 0:
+		position.Outcome = models.OutcomeNoVotes
+	case leaders > 1:
+		position.Outcome = models.OutcomeTie
+	default:
+		position.Outcome = models.OutcomeDecided
+	}
+	for i := range position.Candidates {
+		candidate := &position.Candidates[i]
+		candidate.IsWinner = position.Outcome == models.OutcomeDecided && int64(candidate.VoteCount) == top
+	}
+}
+
+// computeElectionWinners stores vote counts, winners and outcomes on every
+// position of a closed election
+func computeElectionWinners(ctx context.Context, electionID primitive.ObjectID) error {
+	collection := config.GetCollection("elections")
+
+	var election models.Election
+	if err := collection.FindOne(ctx, bson.M{"_id": electionID}).Decode(&election); err != nil {
+		return err
+	}
+
+	counts, err := voteTallies(ctx, electionID)
+	if err != nil {
+		return err
+	}
+
+	for i := range election.Positions {
+		decidePosition(&election.Positions[i], counts[election.Positions[i].ID])
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": electionID}, bson.M{"$set": bson.M{
+		"positions": election.Positions,
+		"updatedAt": time.Now(),
+	}})
+	return err
+}
+
+// clearElectionResults removes the vote counts, winners and outcomes stored
+// when an election closed, including admin overrides, once it is open
+// again. Stored counts would otherwise show live tallies, and the winners
+// would stand before the new votes are counted.
+func clearElectionResults(ctx context.Context, electionID primitive.ObjectID) error {
+	collection := config.GetCollection("elections")
+
+	var election models.Election
+	if err := collection.FindOne(ctx, bson.M{"_id": electionID}).Decode(&election); err != nil {
+		return err
+	}
+
+	for i := range election.Positions {
+		position := &election.Positions[i]
+		position.Outcome = ""
+		for j := range position.Candidates {
+			position.Candidates[j].IsWinner = false
+			position.Candidates[j].VoteCount = 0
+		}
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": electionID, "status": models.ElectionOpen}, bson.M{"$set": bson.M{
+		"positions": election.Positions,
+		"updatedAt": time.Now(),
+	}})
+	return err
+}
+
+// GetElectionResults returns every position and candidate of an election
+// with their vote counts, percentages and turnout against eligible students.
+// Until the election closes only admins see the tallies; everyone else gets
+// the turnout alone.
+func GetElectionResults(c *gin.Context) {
+	id := c.Param("id")
+	electionObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var election models.Election
+	if err := config.GetCollection("elections").FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	byLevel, err := studentsByLevel(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count eligible voters"})
+		return
+	}
+	electionLevel := 0
+	if election.ElectionType == "level-based" {
+		electionLevel = election.TargetLevel
+	}
+
+	voterIDs, err := config.GetCollection("voter_roll").Distinct(ctx, "userId", bson.M{
+		"electionId":  electionObjectID,
+		"positions.0": bson.M{"$exists": true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch results"})
+		return
+	}
+
+	role, _ := c.Get("userRole")
+	results := models.ElectionResults{
+		ElectionID:     election.ID,
+		Title:          election.Title,
+		Status:         election.Status,
+		TalliesVisible: role == "admin" || electionClosed(election, time.Now()),
+		EligibleVoters: eligibleVoters(byLevel, electionLevel),
+		Voters:         int64(len(voterIDs)),
+	}
+	results.Turnout = percentage(results.Voters, results.EligibleVoters)
+
+	if !results.TalliesVisible {
+		c.JSON(http.StatusOK, results)
+		return
+	}
+
+	counts, err := voteTallies(ctx, electionObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch results"})
+		return
+	}
+
+	results.Positions = []models.PositionResult{}
+	for _, position := range election.Positions {
+		result := models.PositionResult{
+			PositionID: position.ID,
+			Title:      position.Title,
+			Level:      position.Level,
+			Outcome:    position.Outcome,
+			Candidates: []models.CandidateResult{},
+		}
+
+		switch {
+		case position.Level == 0:
+			result.EligibleVoters = results.EligibleVoters
+		case electionLevel == 0 || electionLevel == position.Level:
+			result.EligibleVoters = byLevel[position.Level]
+		}
+
+		for _, count := range counts[position.ID] {
+			result.TotalVotes += count
+		}
+		result.Turnout = percentage(result.TotalVotes, result.EligibleVoters)
+
+		for _, candidate := range position.Candidates {
+			votes := counts[position.ID][candidate.ID]
+			result.Candidates = append(result.Candidates, models.CandidateResult{
+				CandidateID: candidate.ID,
+				Name:        candidate.Name,
+				ImageURL:    candidate.ImageURL,
+				Votes:       votes,
+				Percentage:  percentage(votes, result.TotalVotes),
+				IsWinner:    candidate.IsWinner,
+			})
+		}
+
+		results.Positions = append(results.Positions, result)
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetElectionAudit checks that every position has exactly as many votes as
+// voter roll entries, which would show votes added or lost outside CastVote
+func GetElectionAudit(c *gin.Context) {
+	id := c.Param("id")
+	electionObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid election ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var election models.Election
+	if err := config.GetCollection("elections").FindOne(ctx, bson.M{"_id": electionObjectID}).Decode(&election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	counts, err := voteTallies(ctx, electionObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch votes"})
+		return
+	}
+
+	rollCounts, err := countByPosition(ctx, "voter_roll", []bson.M{
+		{"$match": bson.M{"electionId": electionObjectID}},
+		{"$unwind": "$positions"},
+		{"$group": bson.M{"_id": "$positions", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch voter roll"})
+		return
+	}
+
+	removedCounts, err := countByPosition(ctx, "removed_votes", []bson.M{
+		{"$match": bson.M{"electionId": electionObjectID}},
+		{"$group": bson.M{"_id": "$positionId", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch removed votes"})
+		return
+	}
+
+	audit := models.ElectionAudit{
+		ElectionID: election.ID,
+		Consistent: true,
+		Positions:  []models.PositionAudit{},
+	}
+	for _, count := range removedCounts {
+		audit.RemovedVotes += count
+	}
+	for _, position := range election.Positions {
+		entry := models.PositionAudit{
+			PositionID:   position.ID,
+			Title:        position.Title,
+			RollEntries:  rollCounts[position.ID],
+			RemovedVotes: removedCounts[position.ID],
+		}
+		for _, count := range counts[position.ID] {
+			entry.Votes += count
+		}
+		entry.Consistent = entry.Votes == entry.RollEntries
+		audit.Consistent = audit.Consistent && entry.Consistent
+
+		audit.Positions = append(audit.Positions, entry)
+	}
+
+	c.JSON(http.StatusOK, audit)
+}
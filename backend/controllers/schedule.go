@@ -0,0 +1,491 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scheduleLocation is the university's time zone (WAT, no daylight saving)
+var scheduleLocation = time.FixedZone("WAT", 60*60)
+
+var scheduleWeekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// parseClock normalises a time of day such as "9:00" to "09:00"
+func parseClock(value string) (string, bool) {
+	for _, layout := range []string{"15:04", "3:04PM", "3:04pm", "3:04 PM", "3:04 pm"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t.Format("15:04"), true
+		}
+	}
+	return "", false
+}
+
+// semesterRange returns when a semester of a session runs. The first
+// semester ends with January, matching currentSemester.
+func semesterRange(session models.AcademicSession, semester string) (time.Time, time.Time) {
+	split := time.Date(session.StartDate.Year()+1, time.February, 1, 0, 0, 0, 0, scheduleLocation)
+	if semester == "first" {
+		return session.StartDate, split
+	}
+	return split, session.EndDate
+}
+
+// scheduleFilter builds a listing filter from ?session= (default current),
+// ?semester=, ?level= and ?courseCode=. On failure it writes the error
+// response and returns false.
+func scheduleFilter(c *gin.Context, ctx context.Context) (bson.M, bool) {
+	session, ok := resolveSession(c, ctx, c.Query("session"), time.Now())
+	if !ok {
+		return nil, false
+	}
+
+	filter := bson.M{"session": session}
+	if semester := c.Query("semester"); semester != "" {
+		filter["semester"] = models.NormalizeSemester(semester)
+	}
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return nil, false
+		}
+		filter["level"] = level
+	}
+	if courseCode := c.Query("courseCode"); courseCode != "" {
+		filter["courseCode"] = models.NormalizeCourseCode(courseCode)
+	}
+	return filter, true
+}
+
+func GetLectureSlots(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter, ok := scheduleFilter(c, ctx)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "level", Value: 1}, {Key: "day", Value: 1}, {Key: "startTime", Value: 1}})
+	cursor, err := config.GetCollection("lecture_slots").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lecture slots"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var slots []models.LectureSlot
+	if err := cursor.All(ctx, &slots); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode lecture slots"})
+		return
+	}
+
+	if slots == nil {
+		slots = []models.LectureSlot{}
+	}
+
+	c.JSON(http.StatusOK, slots)
+}
+
+// buildLectureSlot validates a lecture slot request against the catalog. On
+// failure it writes the error response and returns false.
+func buildLectureSlot(c *gin.Context, ctx context.Context) (models.LectureSlot, bool) {
+	var req models.LectureSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.LectureSlot{}, false
+	}
+
+	day := strings.ToLower(strings.TrimSpace(req.Day))
+	if _, ok := scheduleWeekdays[day]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Day must be a day of the week"})
+		return models.LectureSlot{}, false
+	}
+	start, okStart := parseClock(req.StartTime)
+	end, okEnd := parseClock(req.EndTime)
+	if !okStart || !okEnd {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Times must look like 09:00"})
+		return models.LectureSlot{}, false
+	}
+	if end <= start {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End time must be after start time"})
+		return models.LectureSlot{}, false
+	}
+
+	session, ok := resolveSession(c, ctx, req.Session, time.Now())
+	if !ok {
+		return models.LectureSlot{}, false
+	}
+	course, ok := resolveActiveCourse(c, ctx, session, req.CourseCode)
+	if !ok {
+		return models.LectureSlot{}, false
+	}
+
+	return models.LectureSlot{
+		Session:    session,
+		Semester:   course.Semester,
+		Level:      course.Level,
+		CourseCode: course.Code,
+		CourseName: course.Name,
+		Venue:      strings.TrimSpace(req.Venue),
+		Day:        day,
+		StartTime:  start,
+		EndTime:    end,
+	}, true
+}
+
+// lectureClashes finds slots in the same week of the same semester that
+// overlap the given one in the same venue or for the same level
+func lectureClashes(ctx context.Context, slot models.LectureSlot, exclude primitive.ObjectID) ([]models.ScheduleClash, error) {
+	cursor, err := config.GetCollection("lecture_slots").Find(ctx, bson.M{
+		"_id":       bson.M{"$ne": exclude},
+		"session":   slot.Session,
+		"semester":  slot.Semester,
+		"day":       slot.Day,
+		"startTime": bson.M{"$lt": slot.EndTime},
+		"endTime":   bson.M{"$gt": slot.StartTime},
+		"$or": []bson.M{
+			{"venue": bson.M{"$regex": "^" + regexp.QuoteMeta(slot.Venue) + "$", "$options": "i"}},
+			{"level": slot.Level},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var existing []models.LectureSlot
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+
+	clashes := []models.ScheduleClash{}
+	for _, other := range existing {
+		reason := "level"
+		if strings.EqualFold(other.Venue, slot.Venue) {
+			reason = "venue"
+		}
+		clashes = append(clashes, models.ScheduleClash{ID: other.ID, CourseCode: other.CourseCode, Venue: other.Venue, Reason: reason})
+	}
+	return clashes, nil
+}
+
+func CreateLectureSlot(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	slot, ok := buildLectureSlot(c, ctx)
+	if !ok {
+		return
+	}
+	slot.ID = primitive.NewObjectID()
+	slot.CreatedAt = time.Now()
+	slot.UpdatedAt = time.Now()
+
+	if !checkClashes(c, ctx, func() ([]models.ScheduleClash, error) { return lectureClashes(ctx, slot, slot.ID) }) {
+		return
+	}
+
+	_, err := config.GetCollection("lecture_slots").InsertOne(ctx, slot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create lecture slot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, slot)
+}
+
+func UpdateLectureSlot(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lecture slot ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	slot, ok := buildLectureSlot(c, ctx)
+	if !ok {
+		return
+	}
+	slot.ID = objID
+	slot.UpdatedAt = time.Now()
+
+	if !checkClashes(c, ctx, func() ([]models.ScheduleClash, error) { return lectureClashes(ctx, slot, objID) }) {
+		return
+	}
+
+	var updated models.LectureSlot
+	err = config.GetCollection("lecture_slots").FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{
+			"session":    slot.Session,
+			"semester":   slot.Semester,
+			"level":      slot.Level,
+			"courseCode": slot.CourseCode,
+			"courseName": slot.CourseName,
+			"venue":      slot.Venue,
+			"day":        slot.Day,
+			"startTime":  slot.StartTime,
+			"endTime":    slot.EndTime,
+			"updatedAt":  slot.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lecture slot not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update lecture slot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func DeleteLectureSlot(c *gin.Context) {
+	deleteScheduleEntry(c, "lecture_slots", "Lecture slot")
+}
+
+func GetExamEntries(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter, ok := scheduleFilter(c, ctx)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "startsAt", Value: 1}})
+	cursor, err := config.GetCollection("exam_entries").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exam timetable"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var exams []models.ExamEntry
+	if err := cursor.All(ctx, &exams); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode exam timetable"})
+		return
+	}
+
+	if exams == nil {
+		exams = []models.ExamEntry{}
+	}
+
+	c.JSON(http.StatusOK, exams)
+}
+
+// buildExamEntry validates an exam entry request against the catalog. On
+// failure it writes the error response and returns false.
+func buildExamEntry(c *gin.Context, ctx context.Context) (models.ExamEntry, bool) {
+	var req models.ExamEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.ExamEntry{}, false
+	}
+
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End time must be after start time"})
+		return models.ExamEntry{}, false
+	}
+
+	session, ok := resolveSession(c, ctx, req.Session, req.StartsAt)
+	if !ok {
+		return models.ExamEntry{}, false
+	}
+	course, ok := resolveActiveCourse(c, ctx, session, req.CourseCode)
+	if !ok {
+		return models.ExamEntry{}, false
+	}
+
+	return models.ExamEntry{
+		Session:    session,
+		Semester:   course.Semester,
+		Level:      course.Level,
+		CourseCode: course.Code,
+		CourseName: course.Name,
+		Venue:      strings.TrimSpace(req.Venue),
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+	}, true
+}
+
+// examClashes finds papers that overlap the given one in the same venue or
+// for the same level
+func examClashes(ctx context.Context, exam models.ExamEntry, exclude primitive.ObjectID) ([]models.ScheduleClash, error) {
+	cursor, err := config.GetCollection("exam_entries").Find(ctx, bson.M{
+		"_id":      bson.M{"$ne": exclude},
+		"startsAt": bson.M{"$lt": exam.EndsAt},
+		"endsAt":   bson.M{"$gt": exam.StartsAt},
+		"$or": []bson.M{
+			{"venue": bson.M{"$regex": "^" + regexp.QuoteMeta(exam.Venue) + "$", "$options": "i"}},
+			{"level": exam.Level},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var existing []models.ExamEntry
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+
+	clashes := []models.ScheduleClash{}
+	for _, other := range existing {
+		reason := "level"
+		if strings.EqualFold(other.Venue, exam.Venue) {
+			reason = "venue"
+		}
+		clashes = append(clashes, models.ScheduleClash{ID: other.ID, CourseCode: other.CourseCode, Venue: other.Venue, Reason: reason})
+	}
+	return clashes, nil
+}
+
+func CreateExamEntry(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exam, ok := buildExamEntry(c, ctx)
+	if !ok {
+		return
+	}
+	exam.ID = primitive.NewObjectID()
+	exam.CreatedAt = time.Now()
+	exam.UpdatedAt = time.Now()
+
+	if !checkClashes(c, ctx, func() ([]models.ScheduleClash, error) { return examClashes(ctx, exam, exam.ID) }) {
+		return
+	}
+
+	_, err := config.GetCollection("exam_entries").InsertOne(ctx, exam)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exam entry"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, exam)
+}
+
+func UpdateExamEntry(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exam entry ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exam, ok := buildExamEntry(c, ctx)
+	if !ok {
+		return
+	}
+	exam.ID = objID
+	exam.UpdatedAt = time.Now()
+
+	if !checkClashes(c, ctx, func() ([]models.ScheduleClash, error) { return examClashes(ctx, exam, objID) }) {
+		return
+	}
+
+	var updated models.ExamEntry
+	err = config.GetCollection("exam_entries").FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{
+			"session":    exam.Session,
+			"semester":   exam.Semester,
+			"level":      exam.Level,
+			"courseCode": exam.CourseCode,
+			"courseName": exam.CourseName,
+			"venue":      exam.Venue,
+			"startsAt":   exam.StartsAt,
+			"endsAt":     exam.EndsAt,
+			"updatedAt":  exam.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exam entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exam entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func DeleteExamEntry(c *gin.Context) {
+	deleteScheduleEntry(c, "exam_entries", "Exam entry")
+}
+
+// checkClashes rejects an entry that clashes with existing ones with 409
+// and the list of clashes, unless the admin passes ?force=true. It returns
+// whether the caller should go ahead.
+func checkClashes(c *gin.Context, ctx context.Context, find func() ([]models.ScheduleClash, error)) bool {
+	if c.Query("force") == "true" {
+		return true
+	}
+
+	clashes, err := find()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for clashes"})
+		return false
+	}
+	if len(clashes) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "This clashes with existing entries; resend with force=true to save anyway",
+			"clashes": clashes,
+		})
+		return false
+	}
+	return true
+}
+
+func deleteScheduleEntry(c *gin.Context, collectionName, label string) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + strings.ToLower(label) + " ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := config.GetCollection(collectionName).DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete " + strings.ToLower(label)})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": label + " not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": label + " deleted successfully"})
+}
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var sessionNamePattern = regexp.MustCompile(`^(\d{4})/(\d{4})$`)
+
+func GetSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	sessions := snapshot.sessions
+	if sessions == nil {
+		sessions = []models.AcademicSession{}
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func GetCurrentSession(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	session, ok := snapshot.sessionAt(time.Now())
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No academic sessions have been set up"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// CreateSession adds an academic session and copies a curriculum into it,
+// so admins only have to edit the courses that changed
+func CreateSession(c *gin.Context) {
+	var req models.CreateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	m := sessionNamePattern.FindStringSubmatch(req.Name)
+	if m == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session name must look like 2025/2026"})
+		return
+	}
+	if first, _ := strconv.Atoi(m[1]); strconv.Itoa(first+1) != m[2] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session must span consecutive years"})
+		return
+	}
+	if !req.EndDate.After(req.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after start date"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+
+	if overlap := overlappingSession(snapshot.sessions, primitive.NilObjectID, req.StartDate, req.EndDate); overlap != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dates overlap the " + overlap + " session"})
+		return
+	}
+
+	copyFrom := req.CopyFrom
+	if copyFrom == "" && len(snapshot.sessions) > 0 {
+		copyFrom = snapshot.sessions[len(snapshot.sessions)-1].Name
+	}
+	if _, ok := snapshot.session(copyFrom); copyFrom != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown academic session: " + copyFrom})
+		return
+	}
+
+	session := models.AcademicSession{
+		ID:        primitive.NewObjectID(),
+		Name:      req.Name,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	_, err = config.GetCollection("academic_sessions").InsertOne(ctx, session)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Session " + req.Name + " already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	var courses []interface{}
+	for _, course := range snapshot.courses {
+		if course.Session != copyFrom || course.Retired {
+			continue
+		}
+		course.ID = primitive.NewObjectID()
+		course.Session = session.Name
+		course.CreatedAt = time.Now()
+		course.UpdatedAt = time.Now()
+		courses = append(courses, course)
+	}
+	if len(courses) > 0 {
+		if _, err := config.GetCollection("courses").InsertMany(ctx, courses); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Session created but copying the curriculum failed"})
+			catalog.invalidate()
+			return
+		}
+	}
+
+	catalog.invalidate()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"session":       session,
+		"copiedFrom":    copyFrom,
+		"coursesCopied": len(courses),
+	})
+}
+
+// UpdateSession changes a session's effective dates
+func UpdateSession(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var req models.UpdateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshot, err := catalog.load(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load course catalog"})
+		return
+	}
+
+	var session *models.AcademicSession
+	for i := range snapshot.sessions {
+		if snapshot.sessions[i].ID == objID {
+			found := snapshot.sessions[i]
+			session = &found
+		}
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if req.StartDate != nil {
+		session.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		session.EndDate = *req.EndDate
+	}
+	if !session.EndDate.After(session.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after start date"})
+		return
+	}
+	if overlap := overlappingSession(snapshot.sessions, objID, session.StartDate, session.EndDate); overlap != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dates overlap the " + overlap + " session"})
+		return
+	}
+	session.UpdatedAt = time.Now()
+
+	var updated models.AcademicSession
+	err = config.GetCollection("academic_sessions").FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{
+			"startDate": session.StartDate,
+			"endDate":   session.EndDate,
+			"updatedAt": session.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		return
+	}
+
+	catalog.invalidate()
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// overlappingSession returns the name of a session other than skip whose
+// dates overlap [start, end), or "" if there is none
+func overlappingSession(sessions []models.AcademicSession, skip primitive.ObjectID, start, end time.Time) string {
+	for _, session := range sessions {
+		if session.ID == skip {
+			continue
+		}
+		if start.Before(session.EndDate) && session.StartDate.Before(end) {
+			return session.Name
+		}
+	}
+	return ""
+}
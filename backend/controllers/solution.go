@@ -0,0 +1,496 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// isStaffRole reports whether a role may verify solutions
+func isStaffRole(role interface{}) bool {
+	return role == "admin" || role == "lecturer"
+}
+
+func normalizeQuestionNumber(number string) string {
+	return strings.ToLower(strings.Join(strings.Fields(number), ""))
+}
+
+// solutionFileURL is the stored file a solution owns, if any. Solutions
+// submitted before uploads were recorded carry whatever URL the client sent,
+// which may not be theirs, so their files are never deleted.
+func solutionFileURL(solution models.Solution) string {
+	if solution.UploadID == nil {
+		return ""
+	}
+	return solution.FileURL
+}
+
+// verifiedSolutions drops solutions that haven't been verified yet
+func verifiedSolutions(solutions []models.Solution) []models.Solution {
+	var verified []models.Solution
+	for _, solution := range solutions {
+		if solution.Status == "verified" {
+			verified = append(verified, solution)
+		}
+	}
+	return verified
+}
+
+func SubmitSolution(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	var req models.SubmitSolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questionNumber := normalizeQuestionNumber(req.QuestionNumber)
+	if questionNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Question number is required"})
+		return
+	}
+	if req.Format == "markdown" && strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content is required for markdown solutions"})
+		return
+	}
+	if req.Format == "document" && (req.FileURL == "" || req.FileName == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fileUrl and fileName are required for document solutions"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, _ := c.Get("userId")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+	user, err := findUserByID(ctx, userObjID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	solution := models.Solution{
+		ID:             primitive.NewObjectID().Hex(),
+		QuestionNumber: questionNumber,
+		Format:         req.Format,
+		SubmittedBy:    userObjID,
+		SubmitterName:  user.FirstName + " " + user.LastName,
+		Status:         "pending",
+		CreatedAt:      time.Now(),
+	}
+	if req.Format == "markdown" {
+		solution.Content = req.Content
+	} else {
+		// Only a file the submitter uploaded through the server, and hasn't
+		// attached elsewhere, is accepted: it is deleted from storage if the
+		// solution is rejected
+		var upload models.StoredUpload
+		err := config.GetCollection("uploads").FindOneAndUpdate(
+			ctx,
+			bson.M{"url": req.FileURL, "uploadedBy": userObjID, "usedBy": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"usedBy": "solution " + solution.ID}},
+		).Decode(&upload)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "fileUrl must be a file you uploaded that isn't attached to anything else"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check uploaded file"})
+			return
+		}
+		solution.FileURL = upload.URL
+		solution.FileName = req.FileName
+		solution.UploadID = &upload.ID
+	}
+
+	// Solutions from lecturers don't need a second opinion
+	if isStaffRole(user.Role) {
+		now := time.Now()
+		solution.Status = "verified"
+		solution.ReviewedBy = &userObjID
+		solution.ReviewedAt = &now
+	}
+
+	collection := config.GetCollection("pastquestions")
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{
+			"$push": bson.M{"solutions": solution},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		// Let the file be attached again
+		if solution.UploadID != nil {
+			_, err := config.GetCollection("uploads").UpdateOne(ctx, bson.M{"_id": solution.UploadID}, bson.M{"$unset": bson.M{"usedBy": ""}})
+			if err != nil {
+				log.Printf("Failed to release upload %s: %v", solution.UploadID.Hex(), err)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit solution"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Past question not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, solution)
+}
+
+func GetSolutions(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	collection := config.GetCollection("pastquestions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var pastQuestion models.PastQuestion
+	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&pastQuestion)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Past question not found"})
+		return
+	}
+
+	questionNumber := normalizeQuestionNumber(c.Query("questionNumber"))
+	solutions := []models.Solution{}
+	for _, solution := range verifiedSolutions(pastQuestion.Solutions) {
+		if questionNumber == "" || solution.QuestionNumber == questionNumber {
+			solutions = append(solutions, solution)
+		}
+	}
+
+	c.JSON(http.StatusOK, solutions)
+}
+
+// GetPendingSolutions lists solutions waiting for a lecturer to review them
+func GetPendingSolutions(c *gin.Context) {
+	collection := config.GetCollection("pastquestions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"solutions.status": "pending"}},
+		{"$unwind": "$solutions"},
+		{"$match": bson.M{"solutions.status": "pending"}},
+		{"$sort": bson.M{"solutions.createdAt": 1}},
+		{"$project": bson.M{
+			"_id":            0,
+			"pastQuestionId": "$_id",
+			"title":          1,
+			"courseCode":     1,
+			"year":           1,
+			"solution":       "$solutions",
+		}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pending solutions"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	results := []bson.M{}
+	if err := cursor.All(ctx, &results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode pending solutions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// ReviewSolution lets a lecturer verify or reject a submitted solution
+func ReviewSolution(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+	solutionID := c.Param("solutionId")
+
+	var req models.ReviewSolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	reviewerID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	collection := config.GetCollection("pastquestions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"solutions.$[sol].status":     req.Status,
+		"solutions.$[sol].reviewNote": req.Note,
+		"solutions.$[sol].reviewedBy": reviewerID,
+		"solutions.$[sol].reviewedAt": time.Now(),
+		"updatedAt":                   time.Now(),
+	}}
+	// A rejected solution's file is deleted, so it can't be reviewed again
+	if req.Status == "rejected" {
+		update["$unset"] = bson.M{"solutions.$[sol].fileUrl": ""}
+	}
+
+	var before models.PastQuestion
+	err = collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID, "solutions": bson.M{"$elemMatch": bson.M{"id": solutionID, "status": bson.M{"$ne": "rejected"}}}},
+		update,
+		options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"sol.id": solutionID}},
+		}).SetProjection(bson.M{"solutions": 1}),
+	).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Solution not found or already rejected"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review solution"})
+		return
+	}
+
+	if req.Status == "rejected" {
+		for _, solution := range before.Solutions {
+			if solution.ID == solutionID {
+				removeStoredFiles("solution "+solutionID+" rejected", solutionFileURL(solution))
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Solution " + req.Status + " successfully"})
+}
+
+// GetDiscussionThreads summarises the discussion on each question number
+func GetDiscussionThreads(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	collection := config.GetCollection("pastquestion_comments")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"pastQuestionId": objID}},
+		{"$group": bson.M{
+			"_id":           "$questionNumber",
+			"commentCount":  bson.M{"$sum": 1},
+			"lastCommentAt": bson.M{"$max": "$createdAt"},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+		{"$project": bson.M{
+			"_id":            0,
+			"questionNumber": "$_id",
+			"commentCount":   1,
+			"lastCommentAt":  1,
+		}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch threads"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	threads := []bson.M{}
+	if err := cursor.All(ctx, &threads); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode threads"})
+		return
+	}
+
+	c.JSON(http.StatusOK, threads)
+}
+
+func GetComments(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	collection := config.GetCollection("pastquestion_comments")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"pastQuestionId": objID,
+		"questionNumber": normalizeQuestionNumber(c.Param("number")),
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var comments []models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode comments"})
+		return
+	}
+
+	if comments == nil {
+		comments = []models.Comment{}
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+func CreateComment(c *gin.Context) {
+	id := c.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid past question ID"})
+		return
+	}
+
+	questionNumber := normalizeQuestionNumber(c.Param("number"))
+	if questionNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Question number is required"})
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := config.GetCollection("pastquestions").CountDocuments(ctx, bson.M{"_id": objID})
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Past question not found"})
+		return
+	}
+
+	collection := config.GetCollection("pastquestion_comments")
+
+	var parentID *primitive.ObjectID
+	if req.ParentID != "" {
+		parentObjID, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent comment ID"})
+			return
+		}
+		// Threads are one level deep: replies answer top-level comments
+		count, err := collection.CountDocuments(ctx, bson.M{
+			"_id":            parentObjID,
+			"pastQuestionId": objID,
+			"questionNumber": questionNumber,
+			"parentId":       bson.M{"$exists": false},
+		})
+		if err != nil || count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found in this thread"})
+			return
+		}
+		parentID = &parentObjID
+	}
+
+	userID, _ := c.Get("userId")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+	user, err := findUserByID(ctx, userObjID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	comment := models.Comment{
+		ID:             primitive.NewObjectID(),
+		PastQuestionID: objID,
+		QuestionNumber: questionNumber,
+		ParentID:       parentID,
+		UserID:         userObjID,
+		AuthorName:     user.FirstName + " " + user.LastName,
+		Body:           strings.TrimSpace(req.Body),
+		CreatedAt:      time.Now(),
+	}
+
+	if _, err := collection.InsertOne(ctx, comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteComment removes a comment; only its author or an admin may do so
+func DeleteComment(c *gin.Context) {
+	commentID, err := primitive.ObjectIDFromHex(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	collection := config.GetCollection("pastquestion_comments")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var comment models.Comment
+	err = collection.FindOne(ctx, bson.M{"_id": commentID}).Decode(&comment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment"})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	role, _ := c.Get("userRole")
+	if comment.UserID.Hex() != userID && role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own comments"})
+		return
+	}
+
+	// Replies go with the comment they answer
+	_, err = collection.DeleteMany(ctx, bson.M{"$or": []bson.M{
+		{"_id": commentID},
+		{"parentId": commentID},
+	}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
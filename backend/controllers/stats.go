@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func GetStats(c *gin.Context) {
@@ -29,6 +30,19 @@ func GetStats(c *gin.Context) {
 		totalDownloads = 0
 	}
 
+	// Count past questions and their downloads
+	pastQuestionsCollection := config.GetCollection("pastquestions")
+	totalPastQuestions, err := pastQuestionsCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		totalPastQuestions = 0
+	}
+
+	pastQuestionDownloadsCollection := config.GetCollection("pastquestion_downloads")
+	totalPastQuestionDownloads, err := pastQuestionDownloadsCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		totalPastQuestionDownloads = 0
+	}
+
 	// Count total users
 	usersCollection := config.GetCollection("users")
 	totalUsers, err := usersCollection.CountDocuments(ctx, bson.M{})
@@ -36,9 +50,9 @@ func GetStats(c *gin.Context) {
 		totalUsers = 0
 	}
 
-	// Count active elections (status = "active")
+	// Count active elections (status = "open")
 	electionsCollection := config.GetCollection("elections")
-	activeElections, err := electionsCollection.CountDocuments(ctx, bson.M{"status": "active"})
+	activeElections, err := electionsCollection.CountDocuments(ctx, bson.M{"status": "open"})
 	if err != nil {
 		activeElections = 0
 	}
@@ -67,9 +81,19 @@ func GetStats(c *gin.Context) {
 		cursor.All(ctx, &recentNotes)
 	}
 
+	// Get most downloaded past questions (top 3)
+	pastQuestionCursor, err := pastQuestionsCollection.Find(ctx, bson.M{}, options.Find().
+		SetSort(bson.D{{Key: "downloadCount", Value: -1}}).
+		SetLimit(3).
+		SetProjection(bson.M{"title": 1, "courseCode": 1, "year": 1, "downloadCount": 1}))
+	topPastQuestions := []bson.M{}
+	if err == nil {
+		pastQuestionCursor.All(ctx, &topPastQuestions)
+	}
+
 	// Get active elections with vote counts
 	electionPipeline := bson.A{
-		bson.M{"$match": bson.M{"status": "active"}},
+		bson.M{"$match": bson.M{"status": "open"}},
 		bson.M{"$lookup": bson.M{
 			"from":         "votes",
 			"localField":   "_id",
@@ -91,11 +115,14 @@ func GetStats(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"totalNotes":          totalNotes,
-		"totalDownloads":      totalDownloads,
-		"totalUsers":          totalUsers,
-		"activeElections":     activeElections,
-		"recentNotes":         recentNotes,
-		"activeElectionsList": activeElectionsList,
+		"totalNotes":                 totalNotes,
+		"totalDownloads":             totalDownloads,
+		"totalPastQuestions":         totalPastQuestions,
+		"totalPastQuestionDownloads": totalPastQuestionDownloads,
+		"topPastQuestions":           topPastQuestions,
+		"totalUsers":                 totalUsers,
+		"activeElections":            activeElections,
+		"recentNotes":                recentNotes,
+		"activeElectionsList":        activeElectionsList,
 	})
 }
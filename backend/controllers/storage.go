@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+	"bowen-accounting-backend/utils"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxStorageCleanupAttempts = 8
+
+// deleteStoredFile removes a file from Cloudinary. URLs that don't point at
+// Cloudinary and assets that are already gone count as success.
+func deleteStoredFile(ctx context.Context, fileURL string) error {
+	if fileURL == "" {
+		return nil
+	}
+
+	asset, ok := utils.ParseCloudinaryURL(fileURL)
+	if !ok {
+		log.Printf("Skipping storage cleanup for non-Cloudinary URL: %s", fileURL)
+		return nil
+	}
+
+	result, err := config.CloudinaryInstance.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     asset.PublicID,
+		ResourceType: asset.ResourceType,
+		Type:         asset.Type,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New(result.Error.Message)
+	}
+	if result.Result != "ok" && result.Result != "not found" {
+		return fmt.Errorf("unexpected destroy result %q", result.Result)
+	}
+
+	return nil
+}
+
+// removeStoredFiles deletes the given files from storage, queueing a cleanup
+// job for any that fail so they can be retried later
+func removeStoredFiles(reason string, fileURLs ...string) {
+	for _, fileURL := range fileURLs {
+		if fileURL == "" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := deleteStoredFile(ctx, fileURL)
+		cancel()
+		if err == nil {
+			continue
+		}
+
+		log.Printf("Failed to delete stored file %s: %v", fileURL, err)
+		if err := enqueueStorageCleanup(fileURL, reason, err); err != nil {
+			log.Printf("Failed to queue storage cleanup for %s: %v", fileURL, err)
+		}
+	}
+}
+
+func enqueueStorageCleanup(fileURL, reason string, cause error) error {
+	collection := config.GetCollection("storage_cleanup_jobs")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job := models.StorageCleanupJob{
+		ID:            primitive.NewObjectID(),
+		FileURL:       fileURL,
+		Reason:        reason,
+		Status:        "pending",
+		Attempts:      1,
+		LastError:     cause.Error(),
+		NextAttemptAt: time.Now().Add(storageCleanupBackoff(1)),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	_, err := collection.InsertOne(ctx, job)
+	return err
+}
+
+func storageCleanupBackoff(attempts int) time.Duration {
+	return time.Duration(1<<uint(attempts)) * time.Minute
+}
+
+// StartStorageCleanupWorker periodically retries queued storage cleanups
+func StartStorageCleanupWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			processStorageCleanupJobs()
+		}
+	}()
+}
+
+func processStorageCleanupJobs() {
+	collection := config.GetCollection("storage_cleanup_jobs")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	filter := bson.M{
+		"status":        "pending",
+		"nextAttemptAt": bson.M{"$lte": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetLimit(50)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Failed to fetch storage cleanup jobs: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.StorageCleanupJob
+	if err := cursor.All(ctx, &jobs); err != nil {
+		log.Printf("Failed to decode storage cleanup jobs: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		set := bson.M{"updatedAt": time.Now()}
+
+		if err := deleteStoredFile(ctx, job.FileURL); err != nil {
+			attempts := job.Attempts + 1
+			set["attempts"] = attempts
+			set["lastError"] = err.Error()
+			set["nextAttemptAt"] = time.Now().Add(storageCleanupBackoff(attempts))
+			if attempts >= maxStorageCleanupAttempts {
+				set["status"] = "failed"
+			}
+		} else {
+			set["status"] = "done"
+			set["lastError"] = ""
+		}
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": set}); err != nil {
+			log.Printf("Failed to update storage cleanup job %s: %v", job.ID.Hex(), err)
+		}
+	}
+}
+
+func GetStorageCleanupJobs(c *gin.Context) {
+	collection := config.GetCollection("storage_cleanup_jobs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cleanup jobs"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.StorageCleanupJob
+	if err := cursor.All(ctx, &jobs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode cleanup jobs"})
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.StorageCleanupJob{}
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// RetryStorageCleanupJob puts a failed cleanup job back in the queue
+func RetryStorageCleanupJob(c *gin.Context) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cleanup job ID"})
+		return
+	}
+
+	collection := config.GetCollection("storage_cleanup_jobs")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "status": bson.M{"$ne": "done"}},
+		bson.M{"$set": bson.M{
+			"status":        "pending",
+			"attempts":      0,
+			"nextAttemptAt": time.Now(),
+			"updatedAt":     time.Now(),
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry cleanup job"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cleanup job not found or already done"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cleanup job queued for retry"})
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+	"bowen-accounting-backend/utils"
+
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	thumbnailFolder         = "thumbnails"
+	thumbnailTransformation = "pg_1,w_400,h_400,c_limit"
+	maxThumbnailSourceBytes = 50 << 20
+	officeConversionPoll    = 3 * time.Second
+)
+
+// renderThumbnail uploads a first-page image for the given document and
+// returns its URL
+func renderThumbnail(ctx context.Context, fileURL, fileType string) (string, error) {
+	params := uploader.UploadParams{
+		Folder:         thumbnailFolder,
+		PublicID:       fmt.Sprintf("thumb_%d", time.Now().UnixNano()),
+		ResourceType:   "image",
+		Format:         "jpg",
+		Transformation: thumbnailTransformation,
+	}
+
+	var source interface{}
+	switch utils.DocumentKind(fileType, fileURL) {
+	case "pdf":
+		// Cloudinary rasterises the first page of a remote PDF itself
+		source = fileURL
+	case "docx", "pptx":
+		data, err := fetchFile(ctx, fileURL)
+		if err != nil {
+			return "", err
+		}
+		// Use the preview saved with the file when there is one; most Word
+		// files don't have one, so fall back to converting the document
+		preview, err := utils.ExtractOfficeThumbnail(data)
+		if err == nil {
+			source = bytes.NewReader(preview)
+			break
+		}
+		if err != utils.ErrNoEmbeddedThumbnail {
+			log.Printf("Failed to read embedded thumbnail from %s: %v", fileURL, err)
+		}
+		pdfURL, cleanup, err := convertOfficeToPDF(ctx, data, utils.DocumentKind(fileType, fileURL))
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		source = pdfURL
+	default:
+		return "", fmt.Errorf("thumbnails are not supported for %q files", fileType)
+	}
+
+	result, err := config.CloudinaryInstance.Upload.Upload(ctx, source, params)
+	if err != nil {
+		return "", err
+	}
+	if result.Error.Message != "" {
+		return "", fmt.Errorf("cloudinary: %s", result.Error.Message)
+	}
+
+	return result.SecureURL, nil
+}
+
+// convertOfficeToPDF uploads a DOCX or PPTX file to Cloudinary with the
+// Aspose conversion add-on and waits for the PDF it produces, so the first
+// page can be rendered like any other PDF. cleanup removes both the upload
+// and the converted PDF once the thumbnail is done.
+func convertOfficeToPDF(ctx context.Context, data []byte, kind string) (string, func(), error) {
+	result, err := config.CloudinaryInstance.Upload.Upload(ctx, bytes.NewReader(data), uploader.UploadParams{
+		Folder:       thumbnailFolder,
+		PublicID:     fmt.Sprintf("convert_%d.%s", time.Now().UnixNano(), kind),
+		ResourceType: "raw",
+		RawConvert:   "aspose",
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if result.Error.Message != "" {
+		return "", nil, fmt.Errorf("cloudinary: %s", result.Error.Message)
+	}
+
+	// The conversion runs in the background and stores the PDF as an image
+	// with the same public ID
+	ticker := time.NewTicker(officeConversionPoll)
+	defer ticker.Stop()
+	for {
+		asset, err := config.CloudinaryInstance.Admin.Asset(ctx, admin.AssetParams{
+			AssetType: api.Image,
+			PublicID:  result.PublicID,
+		})
+		if err == nil && asset.Error.Message == "" && asset.SecureURL != "" {
+			cleanup := func() {
+				removeStoredFiles("office conversion for thumbnail", result.SecureURL, asset.SecureURL)
+			}
+			return asset.SecureURL, cleanup, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			removeStoredFiles("office conversion for thumbnail", result.SecureURL)
+			return "", nil, fmt.Errorf("converting document to PDF: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func fetchFile(ctx context.Context, fileURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned status %d", fileURL, resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxThumbnailSourceBytes))
+}
+
+// generateNoteThumbnail renders and saves the thumbnail for a note
+func generateNoteThumbnail(ctx context.Context, note models.Note) (string, error) {
+	thumbnailURL, err := renderThumbnail(ctx, note.FileURL, note.FileType)
+	if err != nil {
+		return "", err
+	}
+
+	collection := config.GetCollection("notes")
+	_, err = collection.UpdateOne(
+		ctx,
+		bson.M{"_id": note.ID},
+		bson.M{"$set": bson.M{
+			"thumbnailUrl": thumbnailURL,
+			"updatedAt":    time.Now(),
+		}},
+	)
+	if err != nil {
+		// Don't leave an unreferenced image behind
+		removeStoredFiles("thumbnail for note "+note.ID.Hex()+" not saved", thumbnailURL)
+		return "", err
+	}
+
+	return thumbnailURL, nil
+}
+
+// generateNoteThumbnailAsync renders a note's thumbnail in the background so
+// note creation doesn't wait on Cloudinary
+func generateNoteThumbnailAsync(note models.Note) {
+	if note.FileURL == "" || note.ThumbnailURL != "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if _, err := generateNoteThumbnail(ctx, note); err != nil {
+			log.Printf("Failed to generate thumbnail for note %s: %v", note.ID.Hex(), err)
+		}
+	}()
+}
+
+// ThumbnailBackfillResult summarises a thumbnail backfill run
+type ThumbnailBackfillResult struct {
+	Processed int
+	Generated int
+	Skipped   int
+	Failed    int
+}
+
+// BackfillNoteThumbnails generates thumbnails for existing notes that don't
+// have one. With dryRun set it only reports which notes would be processed.
+func BackfillNoteThumbnails(ctx context.Context, limit int64, dryRun bool) (ThumbnailBackfillResult, error) {
+	var result ThumbnailBackfillResult
+
+	collection := config.GetCollection("notes")
+	filter := bson.M{
+		"fileUrl": bson.M{"$ne": ""},
+		"$or": []bson.M{
+			{"thumbnailUrl": ""},
+			{"thumbnailUrl": bson.M{"$exists": false}},
+		},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+	defer cursor.Close(ctx)
+
+	var notes []models.Note
+	if err := cursor.All(ctx, &notes); err != nil {
+		return result, err
+	}
+
+	for _, note := range notes {
+		if limit > 0 && int64(result.Processed) >= limit {
+			break
+		}
+		result.Processed++
+
+		kind := utils.DocumentKind(note.FileType, note.FileURL)
+		if kind != "pdf" && kind != "docx" && kind != "pptx" {
+			result.Skipped++
+			log.Printf("Skipping note %s: unsupported file type %q", note.ID.Hex(), kind)
+			continue
+		}
+
+		if dryRun {
+			log.Printf("Would generate thumbnail for note %s (%s)", note.ID.Hex(), note.Title)
+			continue
+		}
+
+		noteCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		thumbnailURL, err := generateNoteThumbnail(noteCtx, note)
+		cancel()
+		if err != nil {
+			result.Failed++
+			log.Printf("Failed to generate thumbnail for note %s: %v", note.ID.Hex(), err)
+			continue
+		}
+
+		result.Generated++
+		log.Printf("Generated thumbnail for note %s: %s", note.ID.Hex(), thumbnailURL)
+	}
+
+	return result, nil
+}
@@ -10,11 +10,25 @@ import (
 	"time"
 
 	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
 
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var unsafeFilenameChars = regexp.MustCompile("[^a-zA-Z0-9_-]+")
+
+// cleanFilename turns an uploaded file name into something safe to use in a
+// Cloudinary public ID: no extension, spaces as underscores and only
+// alphanumerics, underscores and hyphens
+func cleanFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	baseFilename := filename[:len(filename)-len(ext)]
+	baseFilename = strings.ReplaceAll(baseFilename, " ", "_")
+	return unsafeFilenameChars.ReplaceAllString(baseFilename, "")
+}
+
 func UploadFile(c *gin.Context) {
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -33,21 +47,8 @@ func UploadFile(c *gin.Context) {
 	}
 	defer src.Close()
 
-	// Get file extension
-	ext := filepath.Ext(file.Filename)
-
-	// Create clean filename without extension for public ID
-	baseFilename := file.Filename[:len(file.Filename)-len(ext)]
-
-	// Replace spaces with underscores
-	baseFilename = strings.ReplaceAll(baseFilename, " ", "_")
-
-	// Remove all characters except alphanumeric, underscores, and hyphens
-	reg := regexp.MustCompile("[^a-zA-Z0-9_-]+")
-	baseFilename = reg.ReplaceAllString(baseFilename, "")
-
 	// Create public ID with timestamp
-	publicID := fmt.Sprintf("%d_%s", time.Now().Unix(), baseFilename)
+	publicID := fmt.Sprintf("%d_%s", time.Now().Unix(), cleanFilename(file.Filename))
 
 	// Upload to Cloudinary with longer timeout for large files
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
@@ -79,6 +80,25 @@ func UploadFile(c *gin.Context) {
 	fmt.Printf("Public ID: %s\n", uploadResult.PublicID)
 	fmt.Printf("Cloudinary URL: %s\n", uploadResult.SecureURL)
 
+	// Remember who uploaded the file, so submissions can only attach (and
+	// later delete) files their submitter uploaded
+	userID, _ := c.Get("userId")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+	upload := models.StoredUpload{
+		ID:         primitive.NewObjectID(),
+		URL:        uploadResult.SecureURL,
+		PublicID:   uploadResult.PublicID,
+		UploadedBy: userObjID,
+		CreatedAt:  time.Now(),
+	}
+	dbCtx, dbCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer dbCancel()
+	if _, err := config.GetCollection("uploads").InsertOne(dbCtx, upload); err != nil {
+		removeStoredFiles("upload not recorded", uploadResult.SecureURL)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record upload"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"url":      uploadResult.SecureURL,
 		"publicId": uploadResult.PublicID,
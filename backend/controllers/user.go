@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"bowen-accounting-backend/config"
@@ -14,6 +15,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+func findUserByID(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	var user models.User
+	err := config.GetCollection("users").FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	return user, err
+}
+
 func GetProfile(c *gin.Context) {
 	userID, _ := c.Get("userId")
 	objectID, _ := primitive.ObjectIDFromHex(userID.(string))
@@ -32,28 +39,68 @@ func GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// GetDownloadHistory returns the user's note and past question downloads,
+// newest first
 func GetDownloadHistory(c *gin.Context) {
 	userID, _ := c.Get("userId")
 	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
-	collection := config.GetCollection("note_downloads")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, bson.M{"userId": userObjectID})
+	history := []models.DownloadHistoryItem{}
+
+	cursor, err := config.GetCollection("note_downloads").Find(ctx, bson.M{"userId": userObjectID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch download history"})
 		return
 	}
-	defer cursor.Close(ctx)
+	var noteDownloads []models.NoteDownload
+	err = cursor.All(ctx, &noteDownloads)
+	cursor.Close(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode downloads"})
+		return
+	}
+	for _, download := range noteDownloads {
+		noteID := download.NoteID
+		history = append(history, models.DownloadHistoryItem{
+			ID:         download.ID,
+			Type:       "note",
+			NoteID:     &noteID,
+			UserID:     download.UserID,
+			DownloadAt: download.DownloadAt,
+		})
+	}
 
-	var downloads []models.NoteDownload
-	if err := cursor.All(ctx, &downloads); err != nil {
+	cursor, err = config.GetCollection("pastquestion_downloads").Find(ctx, bson.M{"userId": userObjectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch download history"})
+		return
+	}
+	var pastQuestionDownloads []models.PastQuestionDownload
+	err = cursor.All(ctx, &pastQuestionDownloads)
+	cursor.Close(ctx)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode downloads"})
 		return
 	}
+	for _, download := range pastQuestionDownloads {
+		pastQuestionID := download.PastQuestionID
+		history = append(history, models.DownloadHistoryItem{
+			ID:             download.ID,
+			Type:           "pastQuestion",
+			PastQuestionID: &pastQuestionID,
+			UserID:         download.UserID,
+			DownloadAt:     download.DownloadAt,
+		})
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].DownloadAt.After(history[j].DownloadAt)
+	})
 
-	c.JSON(http.StatusOK, downloads)
+	c.JSON(http.StatusOK, history)
 }
 
 func GetStudentsByLevel(c *gin.Context) {
@@ -125,3 +172,43 @@ func UpdateProfilePicture(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Profile picture updated successfully"})
 }
+
+// UpdateUserRole lets an admin promote a user to lecturer or admin
+func UpdateUserRole(c *gin.Context) {
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := config.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{
+			"role":      req.Role,
+			"updatedAt": time.Now(),
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
@@ -0,0 +1,480 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/models"
+	"bowen-accounting-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// anonymousID returns a random document ID. Votes and ballots don't use
+// ObjectIDs because those embed the time they were created.
+func anonymousID() (string, error) {
+	return utils.RandomToken(12)
+}
+
+// seedVoterRoll adds an empty roll entry for every student eligible to vote
+// in an election, in random order. It runs when the election opens, so
+// voting only updates entries that are already there and the order of the
+// roll says nothing about the order students voted in. Students already on
+// the roll are left alone.
+func seedVoterRoll(ctx context.Context, electionID primitive.ObjectID) error {
+	var election models.Election
+	if err := config.GetCollection("elections").FindOne(ctx, bson.M{"_id": electionID}).Decode(&election); err != nil {
+		return err
+	}
+
+	filter := bson.M{"role": "student"}
+	if election.ElectionType == "level-based" && election.TargetLevel != 0 {
+		filter["level"] = election.TargetLevel
+	}
+	userIDs, err := config.GetCollection("users").Distinct(ctx, "_id", filter)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	rand.Shuffle(len(userIDs), func(i, j int) { userIDs[i], userIDs[j] = userIDs[j], userIDs[i] })
+
+	entries := make([]interface{}, 0, len(userIDs))
+	for _, value := range userIDs {
+		userID, ok := value.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		id, err := anonymousID()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, models.VoterRollEntry{
+			ID:         id,
+			ElectionID: electionID,
+			UserID:     userID,
+			Positions:  []string{},
+		})
+	}
+
+	_, err = config.GetCollection("voter_roll").InsertMany(ctx, entries, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
+
+// recordVotes adds the chosen positions to the voter's roll entry, stores
+// the choices as anonymous votes and appends them to the election's ballot
+// chain as one ballot, all in one transaction. A voter already on the roll
+// for one of the positions fails with a duplicate key error and nothing is
+// written. The returned ballot carries the receipt.
+func recordVotes(ctx context.Context, voterID, electionID primitive.ObjectID, choices []models.BallotChoice) (models.Ballot, error) {
+	ballot := models.Ballot{ElectionID: electionID, Choices: choices}
+	var err error
+	if ballot.ID, err = anonymousID(); err != nil {
+		return ballot, err
+	}
+	if ballot.Nonce, err = utils.RandomToken(16); err != nil {
+		return ballot, err
+	}
+	// The receipt isn't derived from the ballot, so it can't be matched to
+	// the published chain
+	if ballot.Receipt, err = utils.RandomToken(16); err != nil {
+		return ballot, err
+	}
+	rollID, err := anonymousID()
+	if err != nil {
+		return ballot, err
+	}
+
+	positionIDs := make([]string, 0, len(choices))
+	votes := make([]interface{}, 0, len(choices))
+	for _, choice := range choices {
+		voteID, err := anonymousID()
+		if err != nil {
+			return ballot, err
+		}
+		positionIDs = append(positionIDs, choice.PositionID)
+		votes = append(votes, models.Vote{
+			ID:          voteID,
+			ElectionID:  electionID,
+			PositionID:  choice.PositionID,
+			CandidateID: choice.CandidateID,
+		})
+	}
+
+	if err := ensureBallotChain(ctx, electionID); err != nil {
+		return ballot, err
+	}
+
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		return ballot, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// If the voter already has one of the positions the filter misses
+		// their entry, and the upsert collides with it on the unique index.
+		// Students who weren't on the roll when the election opened are
+		// added here.
+		_, err := config.GetCollection("voter_roll").UpdateOne(
+			sc,
+			bson.M{"electionId": electionID, "userId": voterID, "positions": bson.M{"$nin": positionIDs}},
+			bson.M{
+				"$push":        bson.M{"positions": bson.M{"$each": positionIDs}},
+				"$setOnInsert": bson.M{"_id": rollID},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := config.GetCollection("votes").InsertMany(sc, votes); err != nil {
+			return nil, err
+		}
+		if err := appendToChain(sc, &ballot); err != nil {
+			return nil, err
+		}
+		return config.GetCollection("ballots").InsertOne(sc, ballot)
+	})
+	return ballot, err
+}
+
+// AnonymizeVotes moves voting data stored in earlier layouts to the current
+// one. Voter roll entries kept per position are merged into one per student
+// per election. Each legacy vote, which named its voter, becomes a roll
+// entry plus an anonymous vote; a second vote by the same student for the
+// same position (possible before votes were unique) is moved to
+// removed_votes, keeping the earliest. Ballots lose their voter and timestamp. Everything is rewritten
+// in random order so the roll can't be lined up with the votes or ballots.
+// It is safe to run repeatedly.
+func AnonymizeVotes(ctx context.Context) error {
+	session, err := config.DB.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	grouped, err := groupVoterRoll(ctx, session)
+	if err != nil {
+		return err
+	}
+	moved, duplicates, err := anonymizeLegacyVotes(ctx, session)
+	if err != nil {
+		return err
+	}
+	ballots, err := anonymizeLegacyBallots(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	if moved+duplicates+ballots+grouped > 0 {
+		log.Printf("Anonymized %d votes and %d ballots, merged %d voter roll entries, removed %d duplicate votes",
+			moved, ballots, grouped, duplicates)
+	}
+	return nil
+}
+
+// groupVoterRoll merges voter roll entries kept one per position into one
+// entry per student per election, one election at a time with the students
+// in random order. It returns the number of old entries merged.
+func groupVoterRoll(ctx context.Context, session mongo.Session) (int, error) {
+	rollCollection := config.GetCollection("voter_roll")
+
+	cursor, err := rollCollection.Find(ctx, bson.M{"positionId": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	var legacyRoll []struct {
+		ID         interface{}        `bson:"_id"`
+		ElectionID primitive.ObjectID `bson:"electionId"`
+		PositionID string             `bson:"positionId"`
+		UserID     primitive.ObjectID `bson:"userId"`
+	}
+	err = cursor.All(ctx, &legacyRoll)
+	cursor.Close(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	type voter struct {
+		userID    primitive.ObjectID
+		positions []string
+	}
+	byElection := map[primitive.ObjectID]map[primitive.ObjectID]*voter{}
+	oldIDs := map[primitive.ObjectID][]interface{}{}
+	for _, legacy := range legacyRoll {
+		if byElection[legacy.ElectionID] == nil {
+			byElection[legacy.ElectionID] = map[primitive.ObjectID]*voter{}
+		}
+		v := byElection[legacy.ElectionID][legacy.UserID]
+		if v == nil {
+			v = &voter{userID: legacy.UserID}
+			byElection[legacy.ElectionID][legacy.UserID] = v
+		}
+		v.positions = append(v.positions, legacy.PositionID)
+		oldIDs[legacy.ElectionID] = append(oldIDs[legacy.ElectionID], legacy.ID)
+	}
+
+	for electionID, voters := range byElection {
+		// Map iteration order isn't random enough to rely on
+		order := make([]*voter, 0, len(voters))
+		for _, v := range voters {
+			order = append(order, v)
+		}
+		rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		ids := make([]string, len(order))
+		for i := range order {
+			if ids[i], err = anonymousID(); err != nil {
+				return 0, err
+			}
+		}
+
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			if _, err := rollCollection.DeleteMany(sc, bson.M{"_id": bson.M{"$in": oldIDs[electionID]}}); err != nil {
+				return nil, err
+			}
+			for i, v := range order {
+				_, err := rollCollection.UpdateOne(
+					sc,
+					bson.M{"electionId": electionID, "userId": v.userID},
+					bson.M{
+						"$addToSet":    bson.M{"positions": bson.M{"$each": v.positions}},
+						"$setOnInsert": bson.M{"_id": ids[i]},
+					},
+					options.Update().SetUpsert(true),
+				)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(legacyRoll), nil
+}
+
+// anonymizeLegacyVotes moves votes that named their voter to the voter roll
+// plus anonymous votes, one election per transaction. The roll and the votes
+// are written in separately shuffled orders. Duplicates are copied to
+// removed_votes before they are deleted. It returns the number of votes
+// moved and the number of duplicates dropped.
+func anonymizeLegacyVotes(ctx context.Context, session mongo.Session) (int, int, error) {
+	voteCollection := config.GetCollection("votes")
+	rollCollection := config.GetCollection("voter_roll")
+
+	opts := options.Find().SetSort(bson.D{{Key: "votedAt", Value: 1}})
+	cursor, err := voteCollection.Find(ctx, bson.M{"userId": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	var legacyVotes []struct {
+		ID          primitive.ObjectID `bson:"_id"`
+		ElectionID  primitive.ObjectID `bson:"electionId"`
+		PositionID  string             `bson:"positionId"`
+		CandidateID string             `bson:"candidateId"`
+		UserID      primitive.ObjectID `bson:"userId"`
+	}
+	err = cursor.All(ctx, &legacyVotes)
+	cursor.Close(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	type rollKey struct {
+		userID     primitive.ObjectID
+		positionID string
+	}
+	electionIDs := []primitive.ObjectID{}
+	byElection := map[primitive.ObjectID][]int{}
+	for i, legacy := range legacyVotes {
+		if byElection[legacy.ElectionID] == nil {
+			electionIDs = append(electionIDs, legacy.ElectionID)
+		}
+		byElection[legacy.ElectionID] = append(byElection[legacy.ElectionID], i)
+	}
+
+	moved, duplicates := 0, 0
+	for _, electionID := range electionIDs {
+		// Votes are sorted oldest first, so the first one seen is kept
+		seen := map[rollKey]bool{}
+		var kept []int
+		var legacyIDs []primitive.ObjectID
+		for _, i := range byElection[electionID] {
+			legacy := legacyVotes[i]
+			legacyIDs = append(legacyIDs, legacy.ID)
+			key := rollKey{legacy.UserID, legacy.PositionID}
+			if !seen[key] {
+				seen[key] = true
+				kept = append(kept, i)
+			}
+		}
+
+		rollOrder := append([]int(nil), kept...)
+		voteOrder := append([]int(nil), kept...)
+		rand.Shuffle(len(rollOrder), func(i, j int) { rollOrder[i], rollOrder[j] = rollOrder[j], rollOrder[i] })
+		rand.Shuffle(len(voteOrder), func(i, j int) { voteOrder[i], voteOrder[j] = voteOrder[j], voteOrder[i] })
+
+		rollIDs := map[int]string{}
+		voteIDs := map[int]string{}
+		for _, i := range byElection[electionID] {
+			if rollIDs[i], err = anonymousID(); err != nil {
+				return 0, 0, err
+			}
+			if voteIDs[i], err = anonymousID(); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		electionMoved := 0
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			electionMoved = 0
+			// A vote for a position already on the roll is a duplicate of one
+			// cast in the current layout
+			counted := map[int]bool{}
+			for _, i := range rollOrder {
+				legacy := legacyVotes[i]
+				count, err := rollCollection.CountDocuments(sc, bson.M{
+					"electionId": electionID,
+					"userId":     legacy.UserID,
+					"positions":  legacy.PositionID,
+				})
+				if err != nil {
+					return nil, err
+				}
+				if count > 0 {
+					continue
+				}
+
+				_, err = rollCollection.UpdateOne(
+					sc,
+					bson.M{"electionId": electionID, "userId": legacy.UserID},
+					bson.M{
+						"$addToSet":    bson.M{"positions": legacy.PositionID},
+						"$setOnInsert": bson.M{"_id": rollIDs[i]},
+					},
+					options.Update().SetUpsert(true),
+				)
+				if err != nil {
+					return nil, err
+				}
+				counted[i] = true
+			}
+
+			for _, i := range voteOrder {
+				if !counted[i] {
+					continue
+				}
+				legacy := legacyVotes[i]
+				if _, err := voteCollection.InsertOne(sc, models.Vote{
+					ID:          voteIDs[i],
+					ElectionID:  electionID,
+					PositionID:  legacy.PositionID,
+					CandidateID: legacy.CandidateID,
+				}); err != nil {
+					return nil, err
+				}
+				electionMoved++
+			}
+
+			// Keep a record of every vote dropped, without the voter
+			removed := []interface{}{}
+			for _, i := range byElection[electionID] {
+				if counted[i] {
+					continue
+				}
+				legacy := legacyVotes[i]
+				removed = append(removed, models.RemovedVote{
+					ID:          voteIDs[i],
+					ElectionID:  electionID,
+					PositionID:  legacy.PositionID,
+					CandidateID: legacy.CandidateID,
+					Reason:      "duplicate vote by the same student for the same position",
+					RemovedAt:   time.Now(),
+				})
+			}
+			if len(removed) > 0 {
+				if _, err := config.GetCollection("removed_votes").InsertMany(sc, removed); err != nil {
+					return nil, err
+				}
+			}
+
+			return voteCollection.DeleteMany(sc, bson.M{"_id": bson.M{"$in": legacyIDs}})
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+
+		moved += electionMoved
+		duplicates += len(legacyIDs) - electionMoved
+	}
+
+	return moved, duplicates, nil
+}
+
+// anonymizeLegacyBallots rewrites ballots that named their voter or have an
+// ObjectID, in random order. It returns the number rewritten.
+func anonymizeLegacyBallots(ctx context.Context, session mongo.Session) (int, error) {
+	ballotCollection := config.GetCollection("ballots")
+
+	cursor, err := ballotCollection.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"userId": bson.M{"$exists": true}},
+		bson.M{"_id": bson.M{"$type": "objectId"}},
+	}})
+	if err != nil {
+		return 0, err
+	}
+	var legacyBallots []struct {
+		ID         primitive.ObjectID    `bson:"_id"`
+		ElectionID primitive.ObjectID    `bson:"electionId"`
+		Receipt    string                `bson:"receipt"`
+		Choices    []models.BallotChoice `bson:"choices"`
+	}
+	err = cursor.All(ctx, &legacyBallots)
+	cursor.Close(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rand.Shuffle(len(legacyBallots), func(i, j int) {
+		legacyBallots[i], legacyBallots[j] = legacyBallots[j], legacyBallots[i]
+	})
+
+	for _, legacy := range legacyBallots {
+		id, err := anonymousID()
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			// Delete first: the replacement keeps the same receipt
+			if _, err := ballotCollection.DeleteOne(sc, bson.M{"_id": legacy.ID}); err != nil {
+				return nil, err
+			}
+			return ballotCollection.InsertOne(sc, models.Ballot{
+				ID:         id,
+				ElectionID: legacy.ElectionID,
+				Receipt:    legacy.Receipt,
+				Choices:    legacy.Choices,
+			})
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(legacyBallots), nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"bowen-accounting-backend/config"
+	"bowen-accounting-backend/controllers"
 	"bowen-accounting-backend/routes"
 
 	"github.com/gin-contrib/cors"
@@ -33,6 +34,33 @@ func main() {
 		}
 	}()
 
+	// Create indexes and seed the course catalog
+	if err := config.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create database indexes:", err)
+	}
+
+	// Move votes cast before the voter roll existed, before anyone can vote again
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancelMigrate()
+	if err := controllers.AnonymizeVotes(migrateCtx); err != nil {
+		log.Fatal("Failed to anonymize votes:", err)
+	}
+	if err := controllers.RecordLegacyVotes(migrateCtx); err != nil {
+		log.Fatal("Failed to record legacy votes:", err)
+	}
+
+	// The migration above can take longer than the connection timeout
+	seedCtx, cancelSeed := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelSeed()
+	if err := controllers.SeedCourseCatalog(seedCtx); err != nil {
+		log.Fatal("Failed to seed course catalog:", err)
+	}
+
+	// Uploads in progress when the server stopped will never finish
+	if err := controllers.FailInterruptedBulkUploads(seedCtx); err != nil {
+		log.Fatal("Failed to mark interrupted bulk uploads:", err)
+	}
+
 	// Initialize Cloudinary
 	if err := config.InitCloudinary(); err != nil {
 		log.Fatal("Failed to initialize Cloudinary:", err)
@@ -54,7 +82,7 @@ func main() {
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -74,14 +102,26 @@ func main() {
 		routes.NoteRoutes(api)
 		routes.CourseRoutes(api)
 		routes.ElectionRoutes(api)
+		routes.NominationRoutes(api)
 		routes.UserRoutes(api)
 		routes.AnnouncementRoutes(api)
 		routes.PastQuestionRoutes(api)
 		routes.UploadRoutes(api)
 		routes.ProxyRoutes(api)
 		routes.StatsRoutes(api)
+		routes.StorageRoutes(api)
+		routes.RecommendationRoutes(api)
+		routes.SessionRoutes(api)
+		routes.LecturerRoutes(api)
+		routes.RegistrationRoutes(api)
+		routes.ScheduleRoutes(api)
 	}
 
+	// Background jobs
+	controllers.StartStorageCleanupWorker(5 * time.Minute)
+	controllers.StartRecommendationWorker(6 * time.Hour)
+	controllers.StartElectionScheduler(time.Minute)
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -7,15 +7,18 @@ import (
 )
 
 type Announcement struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title     string             `bson:"title" json:"title" binding:"required"`
-	Content   string             `bson:"content" json:"content" binding:"required"`
-	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title   string             `bson:"title" json:"title" binding:"required"`
+	Content string             `bson:"content" json:"content" binding:"required"`
+	// CourseCodes links the announcement to catalog courses; empty means department-wide
+	CourseCodes []string           `bson:"courseCodes,omitempty" json:"courseCodes,omitempty"`
+	CreatedBy   primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
 
 type CreateAnnouncementRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	Title       string   `json:"title" binding:"required"`
+	Content     string   `json:"content" binding:"required"`
+	CourseCodes []string `json:"courseCodes"`
 }
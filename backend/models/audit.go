@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditLog records a change made to a stored record
+type AuditLog struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	EntityType string                 `bson:"entityType" json:"entityType"` // e.g. "pastquestion"
+	EntityID   primitive.ObjectID     `bson:"entityId" json:"entityId"`
+	Action     string                 `bson:"action" json:"action"` // e.g. "update"
+	Changes    map[string]FieldChange `bson:"changes" json:"changes"`
+	Reason     string                 `bson:"reason,omitempty" json:"reason,omitempty"` // why a manual override was made
+	ActorID    primitive.ObjectID     `bson:"actorId" json:"actorId"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+type FieldChange struct {
+	From interface{} `bson:"from" json:"from"`
+	To   interface{} `bson:"to" json:"to"`
+}
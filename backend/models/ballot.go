@@ -0,0 +1,96 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Ballot is a set of choices for an election, recorded in one transaction
+// alongside the votes and voter roll entries. Like a Vote it doesn't say who
+// cast it. Ballots form a hash chain per election: each one's hash covers
+// its choices, a secret nonce and the previous ballot's hash. The receipt is
+// a separate random token, so the published chain can't be matched to it.
+type Ballot struct {
+	ID         string             `bson:"_id" json:"id"`
+	ElectionID primitive.ObjectID `bson:"electionId" json:"electionId"`
+	Receipt    string             `bson:"receipt" json:"receipt"`
+	Seq        int64              `bson:"seq,omitempty" json:"seq,omitempty"`
+	PrevHash   string             `bson:"prevHash,omitempty" json:"prevHash,omitempty"`
+	Hash       string             `bson:"hash,omitempty" json:"hash,omitempty"`
+	Nonce      string             `bson:"nonce,omitempty" json:"nonce,omitempty"`
+	Choices    []BallotChoice     `bson:"choices" json:"choices"`
+}
+
+// BallotChain is the head of an election's ballot chain. LegacyVotes are
+// votes counted before the chain existed, which have no ballot in it.
+type BallotChain struct {
+	ElectionID  primitive.ObjectID `bson:"_id" json:"electionId"`
+	Seq         int64              `bson:"seq" json:"seq"`
+	HeadHash    string             `bson:"headHash" json:"headHash"`
+	LegacyVotes []LegacyVoteCount  `bson:"legacyVotes" json:"legacyVotes"`
+}
+
+// ChainLink is a ballot as published once its election closes: its place
+// in the chain and its hash, without the receipt, choices or nonce
+type ChainLink struct {
+	Seq      int64  `json:"seq"`
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// PublishedChain is an election's ballot chain as published. The choices
+// are only given as totals, so no ballot's choices can be read from it.
+type PublishedChain struct {
+	ElectionID  primitive.ObjectID          `json:"electionId"`
+	HeadHash    string                      `json:"headHash"`
+	Links       []ChainLink                 `json:"links"`
+	Tallies     map[string]map[string]int64 `json:"tallies"` // chained votes per position and candidate
+	LegacyVotes []LegacyVoteCount           `json:"legacyVotes"`
+}
+
+type LegacyVoteCount struct {
+	PositionID  string `bson:"positionId" json:"positionId"`
+	CandidateID string `bson:"candidateId" json:"candidateId"`
+	Votes       int64  `bson:"votes" json:"votes"`
+}
+
+// ChainVerification is the result of replaying an election's ballot chain
+// and comparing it with the counted votes
+type ChainVerification struct {
+	ElectionID   primitive.ObjectID `json:"electionId"`
+	Valid        bool               `json:"valid"`
+	Ballots      int64              `json:"ballots"`
+	HeadHash     string             `json:"headHash"`
+	BrokenAt     int64              `json:"brokenAt,omitempty"` // first ballot whose link or hash doesn't match
+	VotesInChain int64              `json:"votesInChain"`
+	VotesCounted int64              `json:"votesCounted"`
+	LegacyVotes  int64              `json:"legacyVotes"` // counted votes cast before the chain existed
+	TallyMatches bool               `json:"tallyMatches"`
+	Receipt      *ReceiptCheck      `json:"receipt,omitempty"`
+}
+
+type ReceiptCheck struct {
+	Receipt string `json:"receipt"`
+	Found   bool   `json:"found"`
+	Seq     int64  `json:"seq,omitempty"`
+}
+
+type BallotChoice struct {
+	PositionID  string `bson:"positionId" json:"positionId" binding:"required"`
+	CandidateID string `bson:"candidateId" json:"candidateId" binding:"required"`
+}
+
+type BallotRequest struct {
+	Choices []BallotChoice `json:"choices" binding:"required,min=1,dive"`
+}
+
+// BallotReceipt shows that a ballot is part of its election's chain. It
+// never carries the choices: a receipt that proves how someone voted could
+// be used to buy or coerce votes.
+type BallotReceipt struct {
+	Receipt       string             `json:"receipt"`
+	ElectionID    primitive.ObjectID `json:"electionId"`
+	ElectionTitle string             `json:"electionTitle"`
+	Seq           int64              `json:"seq,omitempty"`
+	PrevHash      string             `json:"prevHash,omitempty"`
+	Hash          string             `json:"hash,omitempty"`
+	ChainLength   int64              `json:"chainLength"`
+	HashValid     bool               `json:"hashValid"`
+}
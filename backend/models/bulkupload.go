@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BulkUploadJob tracks a ZIP of past question scans being uploaded in one go
+type BulkUploadJob struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Status      string             `bson:"status" json:"status"` // "processing", "completed", "failed"
+	Total       int                `bson:"total" json:"total"`
+	Created     int                `bson:"created" json:"created"`
+	Failed      int                `bson:"failed" json:"failed"`
+	Items       []BulkUploadItem   `bson:"items" json:"items"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedBy   primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	CompletedAt *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+}
+
+// BulkUploadItem is the result for one manifest row
+type BulkUploadItem struct {
+	Row            int                 `bson:"row" json:"row"`
+	FileName       string              `bson:"fileName" json:"fileName"`
+	CourseCode     string              `bson:"courseCode" json:"courseCode"`
+	Year           int                 `bson:"year" json:"year"`
+	Status         string              `bson:"status" json:"status"` // "pending", "created", "invalid", "failed"
+	Error          string              `bson:"error,omitempty" json:"error,omitempty"`
+	PastQuestionID *primitive.ObjectID `bson:"pastQuestionId,omitempty" json:"pastQuestionId,omitempty"`
+}
+
+// BulkManifestRow describes one file in a bulk upload archive
+type BulkManifestRow struct {
+	CourseCode  string `json:"courseCode"`
+	Year        int    `json:"year"`
+	Semester    string `json:"semester"`
+	Session     string `json:"session"` // defaults to the session the exam year falls in
+	FileName    string `json:"fileName"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
@@ -1,13 +1,45 @@
 package models
 
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 type Course struct {
-	Code     string `json:"code"`
-	Name     string `json:"name"`
-	Level    int    `json:"level"`
-	Semester string `json:"semester"`
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Session   string             `bson:"session" json:"session"` // academic session this version of the course belongs to
+	Code      string             `bson:"code" json:"code"`
+	Name      string             `bson:"name" json:"name"`
+	Level     int                `bson:"level" json:"level"`
+	Semester  string             `bson:"semester" json:"semester"`
+	Retired   bool               `bson:"retired" json:"retired"` // retired courses can't be used for new content
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+type CreateCourseRequest struct {
+	Code     string `json:"code" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Level    int    `json:"level" binding:"required,oneof=100 200 300 400"`
+	Semester string `json:"semester" binding:"required"`
+	Session  string `json:"session"` // defaults to the current session
 }
 
-var Courses = []Course{
+// UpdateCourseRequest changes only the fields that are set
+type UpdateCourseRequest struct {
+	Name     *string `json:"name"`
+	Level    *int    `json:"level" binding:"omitempty,oneof=100 200 300 400"`
+	Semester *string `json:"semester"`
+	Retired  *bool   `json:"retired"`
+}
+
+// SeedCourses is the original hard-coded catalog. It is copied into the
+// first academic session on first start; after that the database is the
+// source of truth.
+var SeedCourses = []Course{
 	// 100 Level - First Semester
 	{Code: "GST 111", Name: "Communication in English", Level: 100, Semester: "first"},
 	{Code: "LIB 101", Name: "Use of library, Study skills and information communication technology", Level: 100, Semester: "first"},
@@ -91,22 +123,67 @@ var Courses = []Course{
 	{Code: "EES 402", Name: "Unveiling Entrepreneurs", Level: 400, Semester: "second"},
 }
 
-func GetCoursesByLevel(level int) []Course {
-	var courses []Course
-	for _, course := range Courses {
-		if course.Level == level {
-			courses = append(courses, course)
-		}
+var courseCodePattern = regexp.MustCompile(`^([A-Z]+(?:-[A-Z]+)*)\s*-?\s*(\d+)$`)
+
+// NormalizeCourseCode converts user-entered codes such as "acc301" or
+// "bui-acc  105" into the catalog form "ACC 301" / "BUI-ACC 105".
+func NormalizeCourseCode(code string) string {
+	code = strings.ToUpper(strings.Join(strings.Fields(code), " "))
+	if m := courseCodePattern.FindStringSubmatch(code); m != nil {
+		return m[1] + " " + m[2]
 	}
-	return courses
+	return code
 }
 
-func GetCoursesByLevelAndSemester(level int, semester string) []Course {
-	var courses []Course
-	for _, course := range Courses {
-		if course.Level == level && course.Semester == semester {
-			courses = append(courses, course)
-		}
+// CatalogMismatch describes a stored note or past question whose course
+// details disagree with the catalog
+type CatalogMismatch struct {
+	ID            string   `json:"id"`
+	Collection    string   `json:"collection"` // "notes" or "pastquestions"
+	Title         string   `json:"title"`
+	CourseCode    string   `json:"courseCode"`
+	Course        string   `json:"course"`
+	Level         int      `json:"level"`
+	Semester      string   `json:"semester"`
+	Session       string   `json:"session"`
+	SuggestedCode string   `json:"suggestedCode,omitempty"`
+	Issues        []string `json:"issues"`
+}
+
+// NormalizeSemester maps spellings such as "1st", "First Semester" or "2"
+// onto the catalog values "first" and "second". Unrecognised input is
+// returned lower-cased.
+func NormalizeSemester(semester string) string {
+	s := strings.ToLower(strings.TrimSpace(semester))
+	s = strings.TrimSpace(strings.TrimSuffix(s, "semester"))
+	switch s {
+	case "1", "1st", "first":
+		return "first"
+	case "2", "2nd", "second":
+		return "second"
 	}
-	return courses
+	return s
+}
+
+// CourseHub gathers everything about one course for the course detail page
+type CourseHub struct {
+	Course        Course              `json:"course"`
+	Notes         []Note              `json:"notes"`
+	PastQuestions []PastQuestionYear  `json:"pastQuestions"`
+	Lecturers     []Lecturer          `json:"lecturers"`
+	Downloads     CourseDownloadStats `json:"downloads"`
+	Announcements []Announcement      `json:"announcements"`
+}
+
+// PastQuestionYear is the set of past question papers for one exam year
+type PastQuestionYear struct {
+	Year          int            `json:"year"`
+	PastQuestions []PastQuestion `json:"pastQuestions"`
+}
+
+type CourseDownloadStats struct {
+	NoteDownloads         int `json:"noteDownloads"`
+	PastQuestionDownloads int `json:"pastQuestionDownloads"`
+	Total                 int `json:"total"`
+	Last30Days            int `json:"last30Days"`
 }
@@ -7,28 +7,64 @@ import (
 )
 
 type Election struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title        string             `bson:"title" json:"title" binding:"required"`
-	Description  string             `bson:"description" json:"description"`
-	Status       string             `bson:"status" json:"status"` // "upcoming", "open", "closed"
-	IsOpen       bool               `bson:"isOpen" json:"isOpen"` // Toggle to open/close election
-	ElectionType string             `bson:"electionType" json:"electionType"` // "general" or "level-based"
-	TargetLevel  int                `bson:"targetLevel" json:"targetLevel"` // 0 for general, specific level for level-based
-	StartDate    time.Time          `bson:"startDate" json:"startDate"`
-	EndDate      time.Time          `bson:"endDate" json:"endDate"`
-	Positions    []Position         `bson:"positions" json:"positions"`
-	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt    time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title         string             `bson:"title" json:"title" binding:"required"`
+	Description   string             `bson:"description" json:"description"`
+	Status        string             `bson:"status" json:"status"`             // "upcoming", "open", "closed"
+	IsOpen        bool               `bson:"isOpen" json:"isOpen"`             // Toggle to open/close election
+	ElectionType  string             `bson:"electionType" json:"electionType"` // "general" or "level-based"
+	TargetLevel   int                `bson:"targetLevel" json:"targetLevel"`   // 0 for general, specific level for level-based
+	StartDate     time.Time          `bson:"startDate" json:"startDate"`
+	EndDate       time.Time          `bson:"endDate" json:"endDate"`
+	Positions     []Position         `bson:"positions" json:"positions"`
+	StatusHistory []StatusTransition `bson:"statusHistory,omitempty" json:"statusHistory,omitempty"`
+	// Students apply to stand between NominationStart and NominationEnd; the
+	// committee members (and admins) screen the applications
+	NominationStart  time.Time            `bson:"nominationStart" json:"nominationStart"`
+	NominationEnd    time.Time            `bson:"nominationEnd" json:"nominationEnd"`
+	CommitteeMembers []primitive.ObjectID `bson:"committeeMembers,omitempty" json:"committeeMembers,omitempty"`
+	CreatedAt        time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Election statuses. The scheduler moves elections from upcoming to open at
+// StartDate and to closed at EndDate; admins can also toggle them by hand.
+const (
+	ElectionUpcoming = "upcoming"
+	ElectionOpen     = "open"
+	ElectionClosed   = "closed"
+)
+
+// StatusTransition records one change of an election's status and what
+// caused it
+type StatusTransition struct {
+	From      string              `bson:"from" json:"from"`
+	To        string              `bson:"to" json:"to"`
+	Trigger   string              `bson:"trigger" json:"trigger"` // "schedule" or "manual"
+	ChangedBy *primitive.ObjectID `bson:"changedBy,omitempty" json:"changedBy,omitempty"`
+	At        time.Time           `bson:"at" json:"at"`
 }
 
 type Position struct {
-	ID          string      `bson:"id" json:"id"`
-	Title       string      `bson:"title" json:"title"`
-	Description string      `bson:"description" json:"description"`
-	Level       int         `bson:"level" json:"level"` // 100, 200, 300, 400 - 0 means all levels
-	Candidates  []Candidate `bson:"candidates" json:"candidates"`
+	ID                string      `bson:"id" json:"id"`
+	Title             string      `bson:"title" json:"title"`
+	Description       string      `bson:"description" json:"description"`
+	Level             int         `bson:"level" json:"level"` // 100, 200, 300, 400 - 0 means all levels
+	Candidates        []Candidate `bson:"candidates" json:"candidates"`
+	Outcome           string      `bson:"outcome,omitempty" json:"outcome,omitempty"`                     // set once the election closes
+	MinCandidateLevel int         `bson:"minCandidateLevel,omitempty" json:"minCandidateLevel,omitempty"` // lowest level a nominee may be in
 }
 
+// Position outcomes. Winners are computed from the tallies when an election
+// closes; a tie needs a runoff or an admin decision, which is recorded as an
+// override.
+const (
+	OutcomeDecided    = "decided"
+	OutcomeTie        = "tie"
+	OutcomeNoVotes    = "no_votes"
+	OutcomeOverridden = "overridden"
+)
+
 type Candidate struct {
 	ID           string             `bson:"id" json:"id"`
 	UserID       primitive.ObjectID `bson:"userId" json:"userId"`
@@ -41,13 +77,62 @@ type Candidate struct {
 	VoteCount    int                `bson:"voteCount" json:"voteCount"`
 }
 
+// Vote is an anonymous choice for one position. It carries no voter, no
+// timestamp and a random ID (an ObjectID would leak when it was cast), so it
+// can't be matched to the voter roll.
 type Vote struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ID          string             `bson:"_id" json:"id"`
+	ElectionID  primitive.ObjectID `bson:"electionId" json:"electionId"`
+	PositionID  string             `bson:"positionId" json:"positionId"`
+	CandidateID string             `bson:"candidateId" json:"candidateId"`
+}
+
+// VoterRollEntry records which positions a student has voted for in an
+// election, without saying for whom. There is one per student per election,
+// added for every eligible student in random order when the election opens,
+// so voting only fills in Positions. It has a random ID and no timestamp, so
+// the roll can't be lined up with the order of the votes or ballot chain.
+type VoterRollEntry struct {
+	ID         string             `bson:"_id" json:"id"`
+	ElectionID primitive.ObjectID `bson:"electionId" json:"electionId"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	Positions  []string           `bson:"positions" json:"positions"`
+}
+
+// RemovedVote is a vote deleted from the tally, kept so the removal can be
+// audited. Like a Vote it doesn't say who cast it.
+type RemovedVote struct {
+	ID          string             `bson:"_id" json:"id"`
 	ElectionID  primitive.ObjectID `bson:"electionId" json:"electionId"`
 	PositionID  string             `bson:"positionId" json:"positionId"`
 	CandidateID string             `bson:"candidateId" json:"candidateId"`
-	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
-	VotedAt     time.Time          `bson:"votedAt" json:"votedAt"`
+	Reason      string             `bson:"reason" json:"reason"`
+	RemovedAt   time.Time          `bson:"removedAt" json:"removedAt"`
+}
+
+// VotedPosition is a position a student has voted for
+type VotedPosition struct {
+	ElectionID primitive.ObjectID `json:"electionId"`
+	PositionID string             `json:"positionId"`
+}
+
+// ElectionAudit compares the voter roll with the anonymous votes. Each
+// position should have exactly one vote per roll entry. RemovedVotes counts
+// votes deleted from the tally, such as duplicates dropped by a migration.
+type ElectionAudit struct {
+	ElectionID   primitive.ObjectID `json:"electionId"`
+	Consistent   bool               `json:"consistent"`
+	RemovedVotes int64              `json:"removedVotes"`
+	Positions    []PositionAudit    `json:"positions"`
+}
+
+type PositionAudit struct {
+	PositionID   string `json:"positionId"`
+	Title        string `json:"title"`
+	RollEntries  int64  `json:"rollEntries"`
+	Votes        int64  `json:"votes"`
+	RemovedVotes int64  `json:"removedVotes"`
+	Consistent   bool   `json:"consistent"`
 }
 
 type VoteRequest struct {
@@ -55,3 +140,36 @@ type VoteRequest struct {
 	PositionID  string `json:"positionId" binding:"required"`
 	CandidateID string `json:"candidateId" binding:"required"`
 }
+
+// ElectionResults is the tally of an election merged with its positions and
+// candidates. Positions are left out while tallies are hidden.
+type ElectionResults struct {
+	ElectionID     primitive.ObjectID `json:"electionId"`
+	Title          string             `json:"title"`
+	Status         string             `json:"status"`
+	TalliesVisible bool               `json:"talliesVisible"`
+	EligibleVoters int64              `json:"eligibleVoters"`
+	Voters         int64              `json:"voters"`
+	Turnout        float64            `json:"turnout"` // percentage of eligible voters who voted
+	Positions      []PositionResult   `json:"positions,omitempty"`
+}
+
+type PositionResult struct {
+	PositionID     string            `json:"positionId"`
+	Title          string            `json:"title"`
+	Level          int               `json:"level"`
+	EligibleVoters int64             `json:"eligibleVoters"`
+	TotalVotes     int64             `json:"totalVotes"`
+	Turnout        float64           `json:"turnout"`
+	Outcome        string            `json:"outcome,omitempty"`
+	Candidates     []CandidateResult `json:"candidates"`
+}
+
+type CandidateResult struct {
+	CandidateID string  `json:"candidateId"`
+	Name        string  `json:"name"`
+	ImageURL    string  `json:"imageUrl"`
+	Votes       int64   `json:"votes"`
+	Percentage  float64 `json:"percentage"` // share of the position's votes
+	IsWinner    bool    `json:"isWinner"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Lecturer struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name    string             `bson:"name" json:"name"`
+	Title   string             `bson:"title" json:"title"` // e.g. "Dr.", "Prof."
+	Email   string             `bson:"email" json:"email"`
+	Office  string             `bson:"office" json:"office"`
+	Courses []LecturerCourse   `bson:"courses" json:"courses"`
+	// Aliases are other spellings of the name seen in free-text fields
+	Aliases   []string  `bson:"aliases" json:"aliases"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// LecturerCourse is a course a lecturer teaches in one academic session
+type LecturerCourse struct {
+	Session    string `bson:"session" json:"session"`
+	CourseCode string `bson:"courseCode" json:"courseCode"`
+}
+
+type CreateLecturerRequest struct {
+	Name    string           `json:"name" binding:"required"`
+	Title   string           `json:"title"`
+	Email   string           `json:"email" binding:"omitempty,email"`
+	Office  string           `json:"office"`
+	Courses []LecturerCourse `json:"courses"`
+	Aliases []string         `json:"aliases"`
+}
+
+// UpdateLecturerRequest changes only the fields that are set. Courses and
+// Aliases replace the existing lists.
+type UpdateLecturerRequest struct {
+	Name    *string           `json:"name"`
+	Title   *string           `json:"title"`
+	Email   *string           `json:"email" binding:"omitempty,email"`
+	Office  *string           `json:"office"`
+	Courses *[]LecturerCourse `json:"courses"`
+	Aliases *[]string         `json:"aliases"`
+}
+
+// DisplayName is the title and name, as shown on notes
+func (l Lecturer) DisplayName() string {
+	return strings.TrimSpace(l.Title + " " + l.Name)
+}
+
+var (
+	lecturerTitlePattern = regexp.MustCompile(`(?i)^(prof(essor)?|dr|mr|mrs|ms|miss|engr|barr?)\.?\s+`)
+	nonLetterPattern     = regexp.MustCompile(`[^a-z ]+`)
+)
+
+// SplitLecturerTitle separates a leading title such as "Dr." from a name
+func SplitLecturerTitle(name string) (title, rest string) {
+	name = strings.Join(strings.Fields(name), " ")
+	for {
+		m := lecturerTitlePattern.FindString(name)
+		if m == "" {
+			return strings.TrimSpace(title), name
+		}
+		title += " " + strings.TrimSpace(m)
+		name = name[len(m):]
+	}
+}
+
+// LecturerNameKey reduces a free-text lecturer name to a comparison key, so
+// "Dr. A. Adeyemi" and "dr adeyemi a" are recognised as the same person
+func LecturerNameKey(name string) string {
+	_, rest := SplitLecturerTitle(name)
+	parts := strings.Fields(nonLetterPattern.ReplaceAllString(strings.ToLower(rest), " "))
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Nomination is a student's application to stand for a position in an
+// election. Approved nominations become candidates.
+type Nomination struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ElectionID    primitive.ObjectID  `bson:"electionId" json:"electionId"`
+	PositionID    string              `bson:"positionId" json:"positionId"`
+	PositionTitle string              `bson:"positionTitle" json:"positionTitle"`
+	UserID        primitive.ObjectID  `bson:"userId" json:"userId"`
+	Name          string              `bson:"name" json:"name"`
+	MatricNumber  string              `bson:"matricNumber" json:"matricNumber"`
+	Level         int                 `bson:"level" json:"level"`
+	Manifesto     string              `bson:"manifesto" json:"manifesto"`
+	PhotoURL      string              `bson:"photoUrl" json:"photoUrl"`
+	Status        string              `bson:"status" json:"status"` // "pending", "approved" or "rejected"
+	Reason        string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	ScreenedBy    *primitive.ObjectID `bson:"screenedBy,omitempty" json:"screenedBy,omitempty"`
+	ScreenedAt    *time.Time          `bson:"screenedAt,omitempty" json:"screenedAt,omitempty"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+type CreateNominationRequest struct {
+	PositionID string `json:"positionId" binding:"required"`
+	Manifesto  string `json:"manifesto" binding:"required"`
+	PhotoURL   string `json:"photoUrl" binding:"required"`
+}
+
+type ScreenNominationRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approved rejected"`
+	Reason   string `json:"reason"` // required when rejecting
+}
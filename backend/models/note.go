@@ -7,22 +7,24 @@ import (
 )
 
 type Note struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title         string             `bson:"title" json:"title" binding:"required"`
-	Description   string             `bson:"description" json:"description"`
-	Course        string             `bson:"course" json:"course" binding:"required"`
-	CourseCode    string             `bson:"courseCode" json:"courseCode" binding:"required"`
-	Level         int                `bson:"level" json:"level" binding:"required"`
-	Semester      string             `bson:"semester" json:"semester" binding:"required"`
-	Lecturer      string             `bson:"lecturer" json:"lecturer"`
-	FileType      string             `bson:"fileType" json:"fileType"`
-	FileURL       string             `bson:"fileUrl" json:"fileUrl"`
-	ThumbnailURL  string             `bson:"thumbnailUrl" json:"thumbnailUrl"`
-	UploadedBy    primitive.ObjectID `bson:"uploadedBy" json:"uploadedBy"`
-	UploaderName  string             `bson:"uploaderName" json:"uploaderName"`
-	DownloadCount int                `bson:"downloadCount" json:"downloadCount"`
-	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Title         string              `bson:"title" json:"title" binding:"required"`
+	Description   string              `bson:"description" json:"description"`
+	Course        string              `bson:"course" json:"course"` // Filled from the course catalog
+	CourseCode    string              `bson:"courseCode" json:"courseCode" binding:"required"`
+	Level         int                 `bson:"level" json:"level"`
+	Semester      string              `bson:"semester" json:"semester"`
+	Session       string              `bson:"session" json:"session"`                           // academic session; defaults to the one the note is uploaded in
+	Lecturer      string              `bson:"lecturer" json:"lecturer"`                         // display name; free text on older notes
+	LecturerID    *primitive.ObjectID `bson:"lecturerId,omitempty" json:"lecturerId,omitempty"` // set when linked to a lecturer record
+	FileType      string              `bson:"fileType" json:"fileType"`
+	FileURL       string              `bson:"fileUrl" json:"fileUrl"`
+	ThumbnailURL  string              `bson:"thumbnailUrl" json:"thumbnailUrl"`
+	UploadedBy    primitive.ObjectID  `bson:"uploadedBy" json:"uploadedBy"`
+	UploaderName  string              `bson:"uploaderName" json:"uploaderName"`
+	DownloadCount int                 `bson:"downloadCount" json:"downloadCount"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
 }
 
 type NoteDownload struct {
@@ -31,3 +33,13 @@ type NoteDownload struct {
 	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
 	DownloadAt time.Time          `bson:"downloadAt" json:"downloadAt"`
 }
+
+// DownloadHistoryItem is one entry in a user's combined download history
+type DownloadHistoryItem struct {
+	ID             primitive.ObjectID  `bson:"_id" json:"id"`
+	Type           string              `bson:"type" json:"type"` // "note" or "pastQuestion"
+	NoteID         *primitive.ObjectID `bson:"noteId,omitempty" json:"noteId,omitempty"`
+	PastQuestionID *primitive.ObjectID `bson:"pastQuestionId,omitempty" json:"pastQuestionId,omitempty"`
+	UserID         primitive.ObjectID  `bson:"userId" json:"userId"`
+	DownloadAt     time.Time           `bson:"downloadAt" json:"downloadAt"`
+}
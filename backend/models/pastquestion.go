@@ -7,29 +7,105 @@ import (
 )
 
 type PastQuestion struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title       string             `bson:"title" json:"title" binding:"required"`
-	Description string             `bson:"description" json:"description"`
-	Course      string             `bson:"course" json:"course" binding:"required"`
-	CourseCode  string             `bson:"courseCode" json:"courseCode" binding:"required"`
-	Level       int                `bson:"level" json:"level" binding:"required"` // 100, 200, 300, 400
-	Semester    string             `bson:"semester" json:"semester" binding:"required"`
-	Year        int                `bson:"year" json:"year" binding:"required"`
-	FileURL     string             `bson:"fileUrl" json:"fileUrl" binding:"required"`
-	FileName    string             `bson:"fileName" json:"fileName" binding:"required"`
-	UploadedBy  primitive.ObjectID `bson:"uploadedBy" json:"uploadedBy"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Title         string              `bson:"title" json:"title" binding:"required"`
+	Description   string              `bson:"description" json:"description"`
+	Course        string              `bson:"course" json:"course" binding:"required"`
+	CourseCode    string              `bson:"courseCode" json:"courseCode" binding:"required"`
+	Level         int                 `bson:"level" json:"level" binding:"required"` // 100, 200, 300, 400
+	Semester      string              `bson:"semester" json:"semester" binding:"required"`
+	Year          int                 `bson:"year" json:"year" binding:"required"`
+	Session       string              `bson:"session" json:"session"` // academic session the exam was sat in
+	LecturerID    *primitive.ObjectID `bson:"lecturerId,omitempty" json:"lecturerId,omitempty"`
+	Lecturer      string              `bson:"lecturer,omitempty" json:"lecturer,omitempty"` // display name of LecturerID
+	FileURL       string              `bson:"fileUrl" json:"fileUrl" binding:"required"`
+	FileName      string              `bson:"fileName" json:"fileName" binding:"required"`
+	UploadedBy    primitive.ObjectID  `bson:"uploadedBy" json:"uploadedBy"`
+	DownloadCount int                 `bson:"downloadCount" json:"downloadCount"`
+	Solutions     []Solution          `bson:"solutions,omitempty" json:"solutions,omitempty"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+type PastQuestionDownload struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PastQuestionID primitive.ObjectID `bson:"pastQuestionId" json:"pastQuestionId"`
+	UserID         primitive.ObjectID `bson:"userId" json:"userId"`
+	DownloadAt     time.Time          `bson:"downloadAt" json:"downloadAt"`
+}
+
+// Solution is a worked answer to one question on a past question paper,
+// either an uploaded document or markdown text
+type Solution struct {
+	ID             string              `bson:"id" json:"id"`
+	QuestionNumber string              `bson:"questionNumber" json:"questionNumber"` // e.g. "1", "3b"
+	Format         string              `bson:"format" json:"format"`                 // "document" or "markdown"
+	Content        string              `bson:"content,omitempty" json:"content,omitempty"`
+	FileURL        string              `bson:"fileUrl,omitempty" json:"fileUrl,omitempty"`
+	FileName       string              `bson:"fileName,omitempty" json:"fileName,omitempty"`
+	UploadID       *primitive.ObjectID `bson:"uploadId,omitempty" json:"-"` // set when the file was uploaded through the server
+	SubmittedBy    primitive.ObjectID  `bson:"submittedBy" json:"submittedBy"`
+	SubmitterName  string              `bson:"submitterName" json:"submitterName"`
+	Status         string              `bson:"status" json:"status"` // "pending", "verified", "rejected"
+	ReviewedBy     *primitive.ObjectID `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+	ReviewNote     string              `bson:"reviewNote,omitempty" json:"reviewNote,omitempty"`
+	ReviewedAt     *time.Time          `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// Comment is a post in the discussion thread for one question number
+type Comment struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	PastQuestionID primitive.ObjectID  `bson:"pastQuestionId" json:"pastQuestionId"`
+	QuestionNumber string              `bson:"questionNumber" json:"questionNumber"`
+	ParentID       *primitive.ObjectID `bson:"parentId,omitempty" json:"parentId,omitempty"` // set on replies
+	UserID         primitive.ObjectID  `bson:"userId" json:"userId"`
+	AuthorName     string              `bson:"authorName" json:"authorName"`
+	Body           string              `bson:"body" json:"body"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
 }
 
 type CreatePastQuestionRequest struct {
 	Title       string `json:"title" binding:"required"`
 	Description string `json:"description"`
-	Course      string `json:"course" binding:"required"`
+	Course      string `json:"course"` // Ignored; the catalog name is used
 	CourseCode  string `json:"courseCode" binding:"required"`
-	Level       int    `json:"level" binding:"required,min=100,max=400"`
-	Semester    string `json:"semester" binding:"required"`
+	Level       int    `json:"level"`
+	Semester    string `json:"semester"`
 	Year        int    `json:"year" binding:"required"`
+	Session     string `json:"session"` // defaults to the session the exam year falls in
+	LecturerID  string `json:"lecturerId"`
 	FileURL     string `json:"fileUrl" binding:"required"`
 	FileName    string `json:"fileName" binding:"required"`
 }
+
+// UpdatePastQuestionRequest changes only the fields that are set. The level,
+// semester and course name follow the course code from the catalog.
+type UpdatePastQuestionRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	CourseCode  *string `json:"courseCode"`
+	Year        *int    `json:"year"`
+	Session     *string `json:"session"`
+	LecturerID  *string `json:"lecturerId"` // "" unlinks the lecturer
+	FileURL     *string `json:"fileUrl"`
+	FileName    *string `json:"fileName"`
+}
+
+type SubmitSolutionRequest struct {
+	QuestionNumber string `json:"questionNumber" binding:"required"`
+	Format         string `json:"format" binding:"required,oneof=document markdown"`
+	Content        string `json:"content"`
+	FileURL        string `json:"fileUrl"`
+	FileName       string `json:"fileName"`
+}
+
+type ReviewSolutionRequest struct {
+	Status string `json:"status" binding:"required,oneof=verified rejected"`
+	Note   string `json:"note"`
+}
+
+type CreateCommentRequest struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID string `json:"parentId"`
+}
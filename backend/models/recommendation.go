@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScoredItem is a recommended note or past question and how strongly it is
+// recommended
+type ScoredItem struct {
+	ID    primitive.ObjectID `bson:"id" json:"id"`
+	Score float64            `bson:"score" json:"score"`
+}
+
+// NoteRecommendation holds the precomputed "students also downloaded" list
+// for a single note. ID is the note's ID.
+type NoteRecommendation struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Notes         []ScoredItem       `bson:"notes" json:"notes"`
+	PastQuestions []ScoredItem       `bson:"pastQuestions" json:"pastQuestions"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// UserRecommendation holds the precomputed "for you" feed for a student.
+// ID is the user's ID.
+type UserRecommendation struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Notes         []ScoredItem       `bson:"notes" json:"notes"`
+	PastQuestions []ScoredItem       `bson:"pastQuestions" json:"pastQuestions"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CourseRegistration is the set of courses a student has registered for in
+// one semester of a session
+type CourseRegistration struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"userId" json:"userId"`
+	Session  string             `bson:"session" json:"session"`
+	Semester string             `bson:"semester" json:"semester"`
+	Level    int                `bson:"level" json:"level"` // student's level when registering
+	// CatalogSession is the curriculum the courses were chosen from, i.e.
+	// the session the student was admitted under
+	CatalogSession string             `bson:"catalogSession" json:"catalogSession"`
+	Courses        []RegisteredCourse `bson:"courses" json:"courses"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+type RegisteredCourse struct {
+	CourseCode string `bson:"courseCode" json:"courseCode"`
+	Name       string `bson:"name" json:"name"`
+	Level      int    `bson:"level" json:"level"`
+	CarryOver  bool   `bson:"carryOver" json:"carryOver"` // a lower-level course being retaken
+}
+
+type RegisterCoursesRequest struct {
+	Semester    string   `json:"semester"` // defaults to the current semester
+	CourseCodes []string `json:"courseCodes" binding:"required,min=1"`
+}
+
+// CourseEnrolment is the number of students registered for a course
+type CourseEnrolment struct {
+	CourseCode string `bson:"_id" json:"courseCode"`
+	Name       string `bson:"name" json:"name"`
+	Level      int    `bson:"level" json:"level"`
+	Students   int    `bson:"students" json:"students"`
+	CarryOvers int    `bson:"carryOvers" json:"carryOvers"`
+}
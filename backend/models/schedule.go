@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LectureSlot is a weekly lecture for a course. Times are "15:04" in the
+// university's local time.
+type LectureSlot struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Session    string             `bson:"session" json:"session"`
+	Semester   string             `bson:"semester" json:"semester"`
+	Level      int                `bson:"level" json:"level"`
+	CourseCode string             `bson:"courseCode" json:"courseCode"`
+	CourseName string             `bson:"courseName" json:"courseName"`
+	Venue      string             `bson:"venue" json:"venue"`
+	Day        string             `bson:"day" json:"day"` // "monday" ... "sunday"
+	StartTime  string             `bson:"startTime" json:"startTime"`
+	EndTime    string             `bson:"endTime" json:"endTime"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ExamEntry is one paper on the exam timetable
+type ExamEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Session    string             `bson:"session" json:"session"`
+	Semester   string             `bson:"semester" json:"semester"`
+	Level      int                `bson:"level" json:"level"`
+	CourseCode string             `bson:"courseCode" json:"courseCode"`
+	CourseName string             `bson:"courseName" json:"courseName"`
+	Venue      string             `bson:"venue" json:"venue"`
+	StartsAt   time.Time          `bson:"startsAt" json:"startsAt"`
+	EndsAt     time.Time          `bson:"endsAt" json:"endsAt"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// LectureSlotRequest creates or replaces a lecture slot. Level and semester
+// come from the course in the session's catalog.
+type LectureSlotRequest struct {
+	Session    string `json:"session"` // defaults to the current session
+	CourseCode string `json:"courseCode" binding:"required"`
+	Venue      string `json:"venue" binding:"required"`
+	Day        string `json:"day" binding:"required"`
+	StartTime  string `json:"startTime" binding:"required"`
+	EndTime    string `json:"endTime" binding:"required"`
+}
+
+type ExamEntryRequest struct {
+	Session    string    `json:"session"` // defaults to the session StartsAt falls in
+	CourseCode string    `json:"courseCode" binding:"required"`
+	Venue      string    `json:"venue" binding:"required"`
+	StartsAt   time.Time `json:"startsAt" binding:"required"`
+	EndsAt     time.Time `json:"endsAt" binding:"required"`
+}
+
+// ScheduleClash describes an existing entry that a new one overlaps with
+type ScheduleClash struct {
+	ID         primitive.ObjectID `json:"id"`
+	CourseCode string             `json:"courseCode"`
+	Venue      string             `json:"venue"`
+	Reason     string             `json:"reason"` // "venue" or "level"
+}
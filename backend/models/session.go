@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AcademicSession is one academic year, e.g. "2025/2026". Each session has
+// its own version of the course catalog; EndDate is exclusive.
+type AcademicSession struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	StartDate time.Time          `bson:"startDate" json:"startDate"`
+	EndDate   time.Time          `bson:"endDate" json:"endDate"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CreateSessionRequest starts a new session. Its curriculum is copied from
+// CopyFrom, or from the latest existing session when that is empty.
+type CreateSessionRequest struct {
+	Name      string    `json:"name" binding:"required"`
+	StartDate time.Time `json:"startDate" binding:"required"`
+	EndDate   time.Time `json:"endDate" binding:"required"`
+	CopyFrom  string    `json:"copyFrom"`
+}
+
+type UpdateSessionRequest struct {
+	StartDate *time.Time `json:"startDate"`
+	EndDate   *time.Time `json:"endDate"`
+}
+
+// DefaultSessionFor returns the session that Sep-Aug academic calendars
+// would place t in, for use before any sessions have been configured
+func DefaultSessionFor(t time.Time) AcademicSession {
+	startYear := t.Year()
+	if t.Month() < time.September {
+		startYear--
+	}
+	return AcademicSession{
+		Name:      SessionName(startYear),
+		StartDate: time.Date(startYear, time.September, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(startYear+1, time.September, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// SessionName formats the session starting in startYear, e.g. "2025/2026"
+func SessionName(startYear int) string {
+	return fmt.Sprintf("%d/%d", startYear, startYear+1)
+}
+
+// EstimateEntryYear guesses when a student at the given level started,
+// assuming they haven't repeated a year
+func EstimateEntryYear(level int, now time.Time) int {
+	return DefaultSessionFor(now).StartDate.Year() - (level/100 - 1)
+}
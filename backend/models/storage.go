@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StorageCleanupJob is a stored file that still has to be removed from
+// Cloudinary after its database record was deleted
+type StorageCleanupJob struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	FileURL       string             `bson:"fileUrl" json:"fileUrl"`
+	Reason        string             `bson:"reason" json:"reason"`
+	Status        string             `bson:"status" json:"status"` // "pending", "done", "failed"
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"lastError" json:"lastError"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// StoredUpload records a file uploaded through the upload endpoint, so
+// records that point at it can be checked against who uploaded it. UsedBy
+// is set once something takes ownership of the file.
+type StoredUpload struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL        string             `bson:"url" json:"url"`
+	PublicID   string             `bson:"publicId" json:"publicId"`
+	UploadedBy primitive.ObjectID `bson:"uploadedBy" json:"uploadedBy"`
+	UsedBy     string             `bson:"usedBy,omitempty" json:"usedBy,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -14,9 +14,11 @@ type User struct {
 	MatricNumber   string             `bson:"matricNumber" json:"matricNumber" binding:"required"`
 	PhoneNumber    string             `bson:"phoneNumber" json:"phoneNumber"`
 	Password       string             `bson:"password" json:"-"`
-	Role           string             `bson:"role" json:"role"`   // "student" or "admin"
-	Level          int                `bson:"level" json:"level"` // 100, 200, 300, 400
+	Role           string             `bson:"role" json:"role"`           // "student", "lecturer" or "admin"
+	Level          int                `bson:"level" json:"level"`         // 100, 200, 300, 400
+	EntryYear      int                `bson:"entryYear" json:"entryYear"` // year the student started; picks their curriculum
 	ProfilePicture string             `bson:"profilePicture" json:"profilePicture"`
+	CalendarToken  string             `bson:"calendarToken,omitempty" json:"-"` // secret in the user's .ics feed URL
 	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
@@ -34,6 +36,7 @@ type RegisterRequest struct {
 	PhoneNumber  string `json:"phoneNumber" binding:"required"`
 	Password     string `json:"password" binding:"required,min=8"`
 	Level        int    `json:"level" binding:"required,min=100,max=400"`
+	EntryYear    int    `json:"entryYear" binding:"omitempty,min=2000"` // derived from level when omitted
 }
 
 type AuthResponse struct {
@@ -64,3 +67,7 @@ type OTP struct {
 	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 }
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=student lecturer admin"`
+}
@@ -10,7 +10,7 @@ import (
 func AnnouncementRoutes(router *gin.RouterGroup) {
 	announcements := router.Group("/announcements")
 	{
-		announcements.GET("", controllers.GetAnnouncements)
+		announcements.GET("", middleware.OptionalAuthMiddleware(), controllers.GetAnnouncements)
 		announcements.GET("/:id", controllers.GetAnnouncement)
 		
 		// Admin only routes
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,5 +13,13 @@ func CourseRoutes(router *gin.RouterGroup) {
 		courses.GET("", controllers.GetAllCourses)
 		courses.GET("/level/:level", controllers.GetCoursesByLevel)
 		courses.GET("/level/:level/semester/:semester", controllers.GetCoursesByLevelAndSemester)
+		courses.GET("/my-curriculum", middleware.AuthMiddleware(), controllers.GetMyCurriculum)
+		courses.GET("/:code", controllers.GetCourseHub)
+
+		// Admin only routes
+		courses.GET("/mismatches", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.GetCatalogMismatches)
+		courses.POST("", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.CreateCourse)
+		courses.PUT("/:code", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.UpdateCourse)
+		courses.DELETE("/:code", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.RetireCourse)
 	}
 }
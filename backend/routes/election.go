@@ -12,7 +12,10 @@ func ElectionRoutes(router *gin.RouterGroup) {
 	{
 		elections.GET("", controllers.GetElections)
 		elections.GET("/:id", controllers.GetElectionByID)
-		elections.GET("/:id/results", controllers.GetElectionResults)
+		elections.GET("/:id/results", middleware.OptionalAuthMiddleware(), controllers.GetElectionResults)
+		elections.GET("/ballots/:receipt", controllers.GetBallotByReceipt)
+		elections.GET("/:id/verify", controllers.VerifyBallotChain)
+		elections.GET("/:id/chain", middleware.OptionalAuthMiddleware(), controllers.GetBallotChain)
 		
 		// Protected routes
 		elections.Use(middleware.AuthMiddleware())
@@ -21,7 +24,10 @@ func ElectionRoutes(router *gin.RouterGroup) {
 		elections.DELETE("/:id", middleware.AdminMiddleware(), controllers.DeleteElection)
 		elections.PUT("/:id/toggle", middleware.AdminMiddleware(), controllers.ToggleElection)
 		elections.PUT("/:id/declare-winner", middleware.AdminMiddleware(), controllers.DeclareWinner)
+		elections.GET("/:id/history", middleware.AdminMiddleware(), controllers.GetElectionHistory)
+		elections.GET("/:id/audit", middleware.AdminMiddleware(), controllers.GetElectionAudit)
 		elections.POST("/vote", controllers.CastVote)
+		elections.POST("/:id/ballot", controllers.CastBallot)
 		elections.GET("/my-votes", controllers.GetUserVotes)
 	}
 }
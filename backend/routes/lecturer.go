@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LecturerRoutes(router *gin.RouterGroup) {
+	lecturers := router.Group("/lecturers")
+	{
+		lecturers.GET("", controllers.GetLecturers)
+		lecturers.GET("/:id", controllers.GetLecturer)
+
+		// Admin only routes
+		lecturers.POST("", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.CreateLecturer)
+		lecturers.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.UpdateLecturer)
+		lecturers.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.DeleteLecturer)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NominationRoutes(router *gin.RouterGroup) {
+	elections := router.Group("/elections")
+	elections.Use(middleware.AuthMiddleware())
+	{
+		elections.GET("/nominations/mine", controllers.GetMyNominations)
+		elections.POST("/:id/nominations", middleware.RolesMiddleware("student"), controllers.SubmitNomination)
+
+		// Admins and the election's committee members
+		elections.GET("/:id/nominations", controllers.GetNominations)
+		elections.PUT("/:id/nominations/:nominationId", controllers.ScreenNomination)
+	}
+}
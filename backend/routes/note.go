@@ -10,8 +10,9 @@ import (
 func NoteRoutes(router *gin.RouterGroup) {
 	notes := router.Group("/notes")
 	{
-		notes.GET("", controllers.GetNotes)
+		notes.GET("", middleware.OptionalAuthMiddleware(), controllers.GetNotes)
 		notes.GET("/:id", controllers.GetNoteByID)
+		notes.GET("/:id/also-downloaded", controllers.GetAlsoDownloaded)
 		
 		// Protected routes
 		notes.Use(middleware.AuthMiddleware())
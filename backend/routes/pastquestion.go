@@ -10,11 +10,30 @@ import (
 func PastQuestionRoutes(router *gin.RouterGroup) {
 	pastQuestions := router.Group("/past-questions")
 	{
-		pastQuestions.GET("", controllers.GetPastQuestions)
+		pastQuestions.GET("", middleware.OptionalAuthMiddleware(), controllers.GetPastQuestions)
+		pastQuestions.GET("/popular", controllers.GetPopularPastQuestions)
 		pastQuestions.GET("/:id", controllers.GetPastQuestion)
+		pastQuestions.GET("/:id/solutions", controllers.GetSolutions)
+		pastQuestions.GET("/:id/threads", controllers.GetDiscussionThreads)
+		pastQuestions.GET("/:id/questions/:number/comments", controllers.GetComments)
+
+		// Authenticated routes
+		pastQuestions.POST("/:id/download", middleware.AuthMiddleware(), controllers.DownloadPastQuestion)
+		pastQuestions.POST("/:id/solutions", middleware.AuthMiddleware(), controllers.SubmitSolution)
+		pastQuestions.POST("/:id/questions/:number/comments", middleware.AuthMiddleware(), controllers.CreateComment)
+		pastQuestions.DELETE("/:id/comments/:commentId", middleware.AuthMiddleware(), controllers.DeleteComment)
+
+		// Lecturer routes
+		pastQuestions.GET("/solutions/pending", middleware.AuthMiddleware(), middleware.RolesMiddleware("admin", "lecturer"), controllers.GetPendingSolutions)
+		pastQuestions.PUT("/:id/solutions/:solutionId/review", middleware.AuthMiddleware(), middleware.RolesMiddleware("admin", "lecturer"), controllers.ReviewSolution)
 		
 		// Admin only routes
 		pastQuestions.POST("", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.CreatePastQuestion)
+		pastQuestions.POST("/bulk", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.BulkUploadPastQuestions)
+		pastQuestions.GET("/bulk/:jobId", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.GetBulkUploadJob)
+		pastQuestions.GET("/coverage", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.GetPastQuestionCoverage)
+		pastQuestions.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.UpdatePastQuestion)
 		pastQuestions.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.DeletePastQuestion)
+		pastQuestions.GET("/:id/history", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.GetPastQuestionHistory)
 	}
 }
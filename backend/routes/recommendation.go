@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RecommendationRoutes(router *gin.RouterGroup) {
+	recommendations := router.Group("/recommendations")
+	recommendations.Use(middleware.AuthMiddleware())
+	{
+		recommendations.GET("/for-you", controllers.GetForYou)
+		recommendations.POST("/refresh", middleware.AdminMiddleware(), controllers.RefreshRecommendations)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegistrationRoutes(router *gin.RouterGroup) {
+	registrations := router.Group("/registrations")
+	registrations.Use(middleware.AuthMiddleware())
+	{
+		registrations.GET("/options", middleware.RolesMiddleware("student"), controllers.GetRegistrationOptions)
+		registrations.GET("/me", middleware.RolesMiddleware("student"), controllers.GetMyRegistration)
+		registrations.PUT("/me", middleware.RolesMiddleware("student"), controllers.RegisterCourses)
+
+		// Admin only routes
+		registrations.GET("/enrolment", middleware.AdminMiddleware(), controllers.GetCourseEnrolment)
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ScheduleRoutes(router *gin.RouterGroup) {
+	schedule := router.Group("/schedule")
+	{
+		schedule.GET("/lectures", controllers.GetLectureSlots)
+		schedule.GET("/exams", controllers.GetExamEntries)
+
+		// iCalendar feeds, e.g. /feeds/level/300.ics
+		schedule.GET("/feeds/level/:level", controllers.GetLevelCalendarFeed)
+		schedule.GET("/feeds/student/:token", controllers.GetStudentCalendarFeed)
+
+		// Protected routes
+		schedule.POST("/feed-token", middleware.AuthMiddleware(), controllers.CreateCalendarToken)
+
+		// Admin only routes
+		schedule.POST("/lectures", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.CreateLectureSlot)
+		schedule.PUT("/lectures/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.UpdateLectureSlot)
+		schedule.DELETE("/lectures/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.DeleteLectureSlot)
+		schedule.POST("/exams", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.CreateExamEntry)
+		schedule.PUT("/exams/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.UpdateExamEntry)
+		schedule.DELETE("/exams/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.DeleteExamEntry)
+	}
+}
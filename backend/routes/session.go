@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SessionRoutes(router *gin.RouterGroup) {
+	sessions := router.Group("/sessions")
+	{
+		sessions.GET("", controllers.GetSessions)
+		sessions.GET("/current", controllers.GetCurrentSession)
+
+		// Admin only routes
+		sessions.POST("", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.CreateSession)
+		sessions.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), controllers.UpdateSession)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"bowen-accounting-backend/controllers"
+	"bowen-accounting-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StorageRoutes(router *gin.RouterGroup) {
+	storage := router.Group("/storage")
+	storage.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		storage.GET("/cleanup-jobs", controllers.GetStorageCleanupJobs)
+		storage.POST("/cleanup-jobs/:id/retry", controllers.RetryStorageCleanupJob)
+	}
+}
@@ -15,5 +15,6 @@ func UserRoutes(router *gin.RouterGroup) {
 		users.GET("/downloads", controllers.GetDownloadHistory)
 		users.GET("/students", middleware.AdminMiddleware(), controllers.GetStudentsByLevel)
 		users.PUT("/profile-picture", controllers.UpdateProfilePicture)
+		users.PUT("/:id/role", middleware.AdminMiddleware(), controllers.UpdateUserRole)
 	}
 }
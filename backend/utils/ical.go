@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// ICalEvent is one VEVENT in an iCalendar feed. Times are written in UTC.
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	Start       time.Time
+	End         time.Time
+	// WeeklyUntil makes the event repeat every week until this time
+	WeeklyUntil time.Time
+	Updated     time.Time
+}
+
+const icalTimeFormat = "20060102T150405Z"
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// WriteICal writes events as an RFC 5545 calendar named name
+func WriteICal(w io.Writer, name string, events []ICalEvent) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		// Lines longer than 75 octets are folded onto continuation lines
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut-- // don't split a UTF-8 sequence
+			}
+			bw.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		bw.WriteString(s + "\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Bowen University Accounting Department//Schedule//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icalEscaper.Replace(name))
+
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + event.Updated.UTC().Format(icalTimeFormat))
+		line("DTSTART:" + event.Start.UTC().Format(icalTimeFormat))
+		line("DTEND:" + event.End.UTC().Format(icalTimeFormat))
+		if !event.WeeklyUntil.IsZero() {
+			line("RRULE:FREQ=WEEKLY;UNTIL=" + event.WeeklyUntil.UTC().Format(icalTimeFormat))
+		}
+		line("SUMMARY:" + icalEscaper.Replace(event.Summary))
+		if event.Location != "" {
+			line("LOCATION:" + icalEscaper.Replace(event.Location))
+		}
+		if event.Description != "" {
+			line("DESCRIPTION:" + icalEscaper.Replace(event.Description))
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
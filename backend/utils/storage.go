@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var cloudinaryVersionPattern = regexp.MustCompile(`^v\d+$`)
+
+// StoredAsset identifies a file held in Cloudinary
+type StoredAsset struct {
+	PublicID     string
+	ResourceType string // "image", "video" or "raw"
+	Type         string // delivery type, usually "upload"
+}
+
+// ParseCloudinaryURL extracts the public ID and resource type from a
+// Cloudinary delivery URL such as
+// https://res.cloudinary.com/<cloud>/image/upload/v123/notes/file.pdf
+func ParseCloudinaryURL(fileURL string) (StoredAsset, bool) {
+	u, err := url.Parse(fileURL)
+	if err != nil || !strings.HasSuffix(u.Host, "cloudinary.com") {
+		return StoredAsset{}, false
+	}
+
+	// <cloud>/<resource type>/<delivery type>/[transformations/][version/]<public id>
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 4 {
+		return StoredAsset{}, false
+	}
+
+	asset := StoredAsset{ResourceType: segments[1], Type: segments[2]}
+	rest := segments[3:]
+	for i, segment := range rest {
+		if cloudinaryVersionPattern.MatchString(segment) {
+			rest = rest[i+1:]
+			break
+		}
+	}
+	if len(rest) == 0 {
+		return StoredAsset{}, false
+	}
+
+	publicID, err := url.PathUnescape(strings.Join(rest, "/"))
+	if err != nil {
+		return StoredAsset{}, false
+	}
+
+	// Raw assets keep their extension as part of the public ID
+	if asset.ResourceType != "raw" {
+		publicID = strings.TrimSuffix(publicID, path.Ext(publicID))
+	}
+	asset.PublicID = publicID
+
+	return asset, true
+}
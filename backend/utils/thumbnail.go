@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNoEmbeddedThumbnail is returned when an Office document was saved
+// without a preview image
+var ErrNoEmbeddedThumbnail = errors.New("document has no embedded thumbnail")
+
+// DocumentKind works out whether a note file is a "pdf", "docx" or "pptx"
+// from its declared file type, falling back to the URL extension
+func DocumentKind(fileType, fileURL string) string {
+	kind := strings.ToLower(strings.TrimPrefix(fileType, "."))
+	if kind == "" {
+		kind = strings.ToLower(strings.TrimPrefix(path.Ext(fileURL), "."))
+	}
+	return kind
+}
+
+// ExtractOfficeThumbnail returns the first-page preview that Word and
+// PowerPoint store in docProps/ when a DOCX or PPTX file is saved. Not every
+// file has one, so callers should be ready to render the page themselves.
+func ExtractOfficeThumbnail(data []byte) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range reader.File {
+		name := strings.ToLower(file.Name)
+		if !strings.HasPrefix(name, "docprops/thumbnail.") {
+			continue
+		}
+
+		// EMF/WMF previews can't be rendered by Cloudinary
+		switch path.Ext(name) {
+		case ".jpeg", ".jpg", ".png":
+		default:
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		return io.ReadAll(rc)
+	}
+
+	return nil, ErrNoEmbeddedThumbnail
+}
@@ -43,12 +43,13 @@ interface Election {
   positions: Position[];
 }
 
-interface VoteResult {
-  _id: {
-    positionId: string;
+interface PositionResult {
+  positionId: string;
+  totalVotes: number;
+  candidates: {
     candidateId: string;
-  };
-  count: number;
+    votes: number;
+  }[];
 }
 
 export default function ElectionDetailsPage() {
@@ -58,7 +59,7 @@ export default function ElectionDetailsPage() {
   const electionId = params.id as string;
   
   const [election, setElection] = useState<Election | null>(null);
-  const [voteResults, setVoteResults] = useState<VoteResult[]>([]);
+  const [voteResults, setVoteResults] = useState<PositionResult[]>([]);
   const [isLoading, setIsLoading] = useState(true);
   const [error, setError] = useState('');
   const [success, setSuccess] = useState('');
@@ -87,21 +88,23 @@ export default function ElectionDetailsPage() {
   const fetchVoteResults = async () => {
     try {
       const response = await api.get(`/elections/${electionId}/results`);
-      setVoteResults(response.data || []);
+      setVoteResults(response.data?.positions || []);
     } catch (err) {
       console.error('Failed to fetch results:', err);
     }
   };
 
   const getVoteCount = (candidateId: string): number => {
-    const result = voteResults.find(r => r._id.candidateId === candidateId);
-    return result ? result.count : 0;
+    for (const position of voteResults) {
+      const result = position.candidates.find(c => c.candidateId === candidateId);
+      if (result) return result.votes;
+    }
+    return 0;
   };
 
   const getTotalVotesForPosition = (positionId: string): number => {
-    return voteResults
-      .filter(r => r._id.positionId === positionId)
-      .reduce((sum, r) => sum + r.count, 0);
+    const result = voteResults.find(p => p.positionId === positionId);
+    return result ? result.totalVotes : 0;
   };
 
   const getLeadingCandidate = (position: Position): Candidate | null => {
@@ -118,10 +121,17 @@ export default function ElectionDetailsPage() {
   };
 
   const declareWinner = async (positionId: string, candidateId: string) => {
+    const justification = window.prompt('Why are you overriding the computed result?');
+    if (!justification || !justification.trim()) {
+      showError('A justification is required to declare a winner');
+      return;
+    }
+
     try {
       await api.put(`/elections/${electionId}/declare-winner`, {
         positionId,
         candidateId,
+        justification,
       });
       showSuccess('🏆 Winner declared successfully!');
       fetchElectionDetails();
@@ -134,26 +144,16 @@ export default function ElectionDetailsPage() {
     const confirmToast = toast.info(
       <div>
         <p className="font-semibold mb-2">Close & Finalize Election?</p>
-        <p className="text-sm mb-3">This will close the election and declare the leading candidates as winners.</p>
+        <p className="text-sm mb-3">This will close the election and declare the leading candidates as winners. Tied positions are flagged for a decision.</p>
         <div className="flex space-x-2">
           <button
             onClick={async () => {
               toast.dismiss(confirmToast);
               try {
                 showInfo('Processing...');
-                // First, close the election
+                // Closing the election computes the winners; ties are left for a decision
                 await api.put(`/elections/${electionId}/toggle`, { isOpen: false });
 
-                // Then declare winners for each position (candidate with most votes)
-                if (election) {
-                  for (const position of election.positions) {
-                    const leadingCandidate = getLeadingCandidate(position);
-                    if (leadingCandidate && getVoteCount(leadingCandidate.id) > 0) {
-                      await declareWinner(position.id, leadingCandidate.id);
-                    }
-                  }
-                }
-
                 showSuccess('🎉 Election closed and winners declared successfully!');
                 fetchElectionDetails();
               } catch (err: any) {
@@ -101,7 +101,7 @@ export default function VotePage() {
   const fetchVoteResults = async (electionId: string) => {
     try {
       const response = await api.get(`/elections/${electionId}/results`);
-      setVoteResultsMap(prev => ({ ...prev, [electionId]: response.data || [] }));
+      setVoteResultsMap(prev => ({ ...prev, [electionId]: response.data?.positions || [] }));
     } catch (err) {
       
     }
@@ -109,15 +109,17 @@ export default function VotePage() {
 
   const getVoteCount = (electionId: string, candidateId: string): number => {
     const results = voteResultsMap[electionId] || [];
-    const result = results.find((r: any) => r._id.candidateId === candidateId);
-    return result ? result.count : 0;
+    for (const position of results) {
+      const result = position.candidates.find((c: any) => c.candidateId === candidateId);
+      if (result) return result.votes;
+    }
+    return 0;
   };
 
   const getTotalVotesForPosition = (electionId: string, positionId: string): number => {
     const results = voteResultsMap[electionId] || [];
-    return results
-      .filter((r: any) => r._id.positionId === positionId)
-      .reduce((sum: number, r: any) => sum + r.count, 0);
+    const result = results.find((p: any) => p.positionId === positionId);
+    return result ? result.totalVotes : 0;
   };
 
   const fetchMyVotes = async () => {